@@ -0,0 +1,85 @@
+// Package clipboard copies text to the system clipboard for vinw and vinw-viewer
+package clipboard
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// tools are tried in order, the first one installed is used
+var tools = [][]string{
+	{"pbcopy"},                           // macOS
+	{"wl-copy"},                          // Wayland
+	{"xclip", "-selection", "clipboard"}, // X11
+	{"clip.exe"},                         // WSL
+}
+
+// osc52Limit is the largest base64 payload terminals reliably accept in an OSC52 sequence
+const osc52Limit = 74994
+
+// Copy copies text using the platform's clipboard tool, returning an error if
+// the copy fails. With VINW_CLIPBOARD=osc52, when no tool can be used, or when
+// the tool fails, the text is sent to the terminal as an OSC52 escape sequence
+// instead, which reaches the local clipboard over SSH.
+func Copy(text string) error {
+	if os.Getenv("VINW_CLIPBOARD") == "osc52" {
+		return copyOSC52(text)
+	}
+
+	for _, tool := range tools {
+		// wl-copy and xclip need a display, which an SSH session usually lacks
+		if tool[0] == "wl-copy" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		if tool[0] == "xclip" && os.Getenv("DISPLAY") == "" {
+			continue
+		}
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool[0], tool[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			// The terminal may still be able to take it
+			if osc52Err := copyOSC52(text); osc52Err != nil {
+				return fmt.Errorf("clipboard copy failed with %s: %w", tool[0], err)
+			}
+			return nil
+		}
+		return nil
+	}
+
+	// No local tool, let the terminal emulator do it
+	return copyOSC52(text)
+}
+
+// copyOSC52 writes an OSC52 copy sequence to the terminal, wrapped for tmux or
+// screen when running inside them. It goes to stderr so it doesn't interleave
+// with Bubble Tea's buffered frames on stdout, and only when stderr is a
+// terminal, otherwise the sequence would land in a file and nothing is copied.
+func copyOSC52(text string) error {
+	if info, err := os.Stderr.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return errors.New("can't copy with OSC52, stderr isn't a terminal")
+	}
+
+	// base64 grows the payload by 4/3
+	if size := (len(text) + 2) / 3 * 4; size > osc52Limit {
+		return fmt.Errorf("too large for OSC52 (%d bytes encoded, limit %d)", size, osc52Limit)
+	}
+
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+	if _, err := seq.WriteTo(os.Stderr); err != nil {
+		return fmt.Errorf("clipboard copy failed writing OSC52: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+module vinw/clipboard
+
+go 1.23.0
+
+require github.com/aymanbagabas/go-osc52/v2 v2.0.1
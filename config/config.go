@@ -0,0 +1,278 @@
+// Package config reads the vinw config files shared by vinw and vinw-viewer
+package config
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config holds settings loaded from ~/.vinw/config.toml, overlaid by a
+// project's .vinw/config.toml
+// Only a small TOML subset is supported: [sections], key = value pairs with
+// strings, integers and booleans, and single-line arrays of those
+type Config struct {
+	values map[string]string
+	lists  map[string][]string
+	keys   []string // Keys in file order, project keys first
+}
+
+// ConfigPath returns the location of the global config file
+func ConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".vinw", "config.toml")
+}
+
+// Load loads the global config file, returning an empty config if it doesn't exist
+func Load() *Config {
+	return LoadFile(ConfigPath())
+}
+
+// ProjectDir finds the project-local .vinw directory by walking up from start,
+// stopping at the repository root (the first directory holding .git). The global
+// ~/.vinw is never a project directory. Returns "" if there is none.
+func ProjectDir(start string) string {
+	home, _ := os.UserHomeDir()
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return ""
+	}
+	for {
+		if dir != home {
+			if info, err := os.Stat(filepath.Join(dir, ".vinw")); err == nil && info.IsDir() {
+				return filepath.Join(dir, ".vinw")
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// LoadFor loads the global config overlaid with the project config for
+// watchPath, so project settings win over global ones. It also returns the
+// project config file, or "" when there is none.
+func LoadFor(watchPath string) (*Config, string) {
+	cfg := Load()
+	projectDir := ProjectDir(watchPath)
+	if projectDir == "" {
+		return cfg, ""
+	}
+	projectPath := filepath.Join(projectDir, "config.toml")
+	if _, err := os.Stat(projectPath); err != nil {
+		return cfg, ""
+	}
+
+	project := LoadFile(projectPath)
+	for key, value := range project.values {
+		cfg.values[key] = value
+		delete(cfg.lists, key)
+	}
+	for key, list := range project.lists {
+		cfg.lists[key] = list
+		delete(cfg.values, key)
+	}
+	// Project keys come first so ordered sections prefer them
+	keys := project.keys
+	for _, key := range cfg.keys {
+		if !project.Has(key) {
+			keys = append(keys, key)
+		}
+	}
+	cfg.keys = keys
+	return cfg, projectPath
+}
+
+// LoadFile parses a config file at the given path
+func LoadFile(path string) *Config {
+	cfg := &Config{
+		values: make(map[string]string),
+		lists:  make(map[string][]string),
+	}
+	if path == "" {
+		return cfg
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		// No config file
+		return cfg
+	}
+	defer file.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		// Section header, keys below are prefixed with "section."
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		// Keys may be quoted to allow characters such as globs
+		key = unquote(strings.TrimSpace(key))
+		if key == "" {
+			continue
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		value = strings.TrimSpace(value)
+		if !cfg.Has(key) {
+			cfg.keys = append(cfg.keys, key)
+		}
+
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			var items []string
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, unquote(item))
+				}
+			}
+			cfg.lists[key] = items
+			delete(cfg.values, key)
+			continue
+		}
+		cfg.values[key] = unquote(value)
+		delete(cfg.lists, key)
+	}
+
+	return cfg
+}
+
+// stripComment removes a trailing # comment that isn't inside quotes
+func stripComment(line string) string {
+	inQuote := rune(0)
+	for i, r := range line {
+		switch {
+		case inQuote != 0 && r == inQuote:
+			inQuote = 0
+		case inQuote == 0 && (r == '"' || r == '\''):
+			inQuote = r
+		case inQuote == 0 && r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote strips matching surrounding quotes from a value
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// Has reports whether a key is set in the config
+func (c *Config) Has(key string) bool {
+	if c == nil {
+		return false
+	}
+	_, isValue := c.values[key]
+	_, isList := c.lists[key]
+	return isValue || isList
+}
+
+// String returns a string setting or the default
+func (c *Config) String(key, def string) string {
+	if c == nil {
+		return def
+	}
+	if value, ok := c.values[key]; ok {
+		return value
+	}
+	return def
+}
+
+// Int returns an integer setting or the default
+func (c *Config) Int(key string, def int) int {
+	if c == nil {
+		return def
+	}
+	if value, err := strconv.Atoi(c.values[key]); err == nil {
+		return value
+	}
+	return def
+}
+
+// Bool returns a boolean setting or the default
+func (c *Config) Bool(key string, def bool) bool {
+	if c == nil {
+		return def
+	}
+	if value, err := strconv.ParseBool(c.values[key]); err == nil {
+		return value
+	}
+	return def
+}
+
+// StringList returns a list setting or the default
+func (c *Config) StringList(key string, def []string) []string {
+	if c == nil {
+		return def
+	}
+	if list, ok := c.lists[key]; ok {
+		return list
+	}
+	return def
+}
+
+// IntList returns a list of integers or the default if any entry is invalid
+func (c *Config) IntList(key string, def []int) []int {
+	if c == nil {
+		return def
+	}
+	list, ok := c.lists[key]
+	if !ok {
+		return def
+	}
+	ints := make([]int, 0, len(list))
+	for _, item := range list {
+		value, err := strconv.Atoi(item)
+		if err != nil {
+			return def
+		}
+		ints = append(ints, value)
+	}
+	return ints
+}
+
+// Section returns the key/value pairs of a [section] in file order, with the
+// section prefix removed. List values are skipped.
+func (c *Config) Section(section string) [][2]string {
+	if c == nil {
+		return nil
+	}
+	prefix := section + "."
+	var pairs [][2]string
+	for _, key := range c.keys {
+		value, ok := c.values[key]
+		if !ok || !strings.HasPrefix(key, prefix) || value == "" {
+			continue
+		}
+		pairs = append(pairs, [2]string{strings.TrimPrefix(key, prefix), value})
+	}
+	return pairs
+}
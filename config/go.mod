@@ -0,0 +1,3 @@
+module vinw/config
+
+go 1.23.0
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package internal
+
+import "syscall"
+
+// dirWritable reports whether the current user may create entries in dir
+func dirWritable(dir string) bool {
+	// W_OK, not exported by syscall on every platform
+	return syscall.Access(dir, 0x2) == nil
+}
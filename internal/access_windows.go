@@ -0,0 +1,12 @@
+//go:build windows
+
+package internal
+
+import "os"
+
+// dirWritable reports whether the current user may create entries in dir. Windows
+// ACLs aren't reflected in the mode bits, so only read-only directories are caught.
+func dirWritable(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.Mode().Perm()&0200 != 0
+}
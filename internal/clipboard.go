@@ -0,0 +1,13 @@
+package internal
+
+import "vinw/clipboard"
+
+// CopyToClipboard copies text with the shared clipboard helper the viewer
+// also uses, logging failures to the event log
+func CopyToClipboard(text string) error {
+	if err := clipboard.Copy(text); err != nil {
+		LogError("clipboard copy failed", err)
+		return err
+	}
+	return nil
+}
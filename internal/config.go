@@ -0,0 +1,19 @@
+package internal
+
+import "vinw/config"
+
+// Config holds settings loaded from ~/.vinw/config.toml, overlaid by a
+// project's .vinw/config.toml. The parser lives in the config module so the
+// viewer reads the same files the same way.
+type Config = config.Config
+
+// ConfigPath returns the location of the global config file
+func ConfigPath() string {
+	return config.ConfigPath()
+}
+
+// LoadConfigFor loads the global config overlaid with the project config for
+// watchPath. It also returns the project config file, or "" when there is none.
+func LoadConfigFor(watchPath string) (*Config, string) {
+	return config.LoadFor(watchPath)
+}
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// eventLog receives debug events when --log is given, nil otherwise
+var eventLog *slog.Logger
+
+// OpenEventLog starts writing JSON event lines to path (appending) and returns
+// a function that closes the file
+func OpenEventLog(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	eventLog = slog.New(slog.NewJSONHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	eventLog.Info("log opened", "pid", os.Getpid(), "args", os.Args[1:])
+	return func() {
+		eventLog.Info("log closed")
+		eventLog = nil
+		file.Close()
+	}, nil
+}
+
+// LogEvent records a debug event with key/value attributes
+func LogEvent(msg string, args ...any) {
+	if eventLog == nil {
+		return
+	}
+	eventLog.Info(msg, args...)
+}
+
+// LogError records a failure that isn't otherwise shown to the user
+func LogError(msg string, err error, args ...any) {
+	if eventLog == nil || err == nil {
+		return
+	}
+	eventLog.Error(msg, append([]any{"error", err.Error()}, args...)...)
+}
+
+// commandOutput runs cmd like cmd.Output, logging the invocation, its timing and any failure
+func commandOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	output, err := cmd.Output()
+	if eventLog != nil {
+		attrs := []any{"cmd", strings.Join(cmd.Args, " "), "dir", cmd.Dir, "duration_ms", time.Since(start).Milliseconds()}
+		if err != nil {
+			eventLog.Error("command failed", append(attrs, "error", err.Error())...)
+		} else {
+			eventLog.Debug("command", attrs...)
+		}
+	}
+	return output, err
+}
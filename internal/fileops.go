@@ -2,8 +2,12 @@ package internal
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 )
 
 // CreateFile creates a new file at the specified path
@@ -116,3 +120,156 @@ func GetParentDirectory(path string) string {
 	}
 	return parent
 }
+
+// SuggestAvailableName returns a name that doesn't exist in dir, derived from name
+// by appending or bumping a numeric suffix before the extension (foo.go -> foo-2.go)
+func SuggestAvailableName(dir, name string) string {
+	if _, err := os.Lstat(filepath.Join(dir, name)); os.IsNotExist(err) {
+		return name
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// Dotfiles like .env have no extension to preserve
+		base, ext = name, ""
+	}
+
+	// Continue an existing suffix instead of stacking them (foo-2 -> foo-3)
+	start := 2
+	if i := strings.LastIndex(base, "-"); i > 0 {
+		if n, err := strconv.Atoi(base[i+1:]); err == nil && n > 0 {
+			base, start = base[:i], n+1
+		}
+	}
+
+	for n := start; ; n++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, n, ext)
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// CopyFile copies a file into dstDir, keeping its name and mode, and returns the new path
+func CopyFile(src, dstDir string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("cannot copy a directory: %s", src)
+	}
+
+	dst := filepath.Join(dstDir, filepath.Base(src))
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("file already exists: %s", dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return "", fmt.Errorf("failed to copy file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return "", fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	return dst, nil
+}
+
+// MoveItem moves a file or directory into dstDir and returns the new path.
+// Files on another filesystem are copied and the original removed.
+func MoveItem(src, dstDir string) (string, error) {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	dst := filepath.Join(dstDir, filepath.Base(src))
+	if _, err := os.Lstat(dst); err == nil {
+		return "", fmt.Errorf("already exists: %s", dst)
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("failed to move directory: %w", err)
+		}
+		// Rename can't cross filesystems, fall back to copy and remove
+		if _, copyErr := CopyFile(src, dstDir); copyErr != nil {
+			return "", fmt.Errorf("failed to move file: %w", err)
+		}
+		if err := os.Remove(src); err != nil {
+			return "", fmt.Errorf("copied but failed to remove original: %w", err)
+		}
+	}
+
+	return dst, nil
+}
+
+// RenameEntry renames a file or directory within its parent directory
+func RenameEntry(oldPath, newName string) error {
+	if newName == "" || newName == "." || newName == ".." || strings.ContainsRune(newName, filepath.Separator) {
+		return fmt.Errorf("invalid name: %q", newName)
+	}
+	if _, err := os.Lstat(oldPath); err != nil {
+		return fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	newPath := filepath.Join(filepath.Dir(oldPath), newName)
+	// Only a case change on a case-insensitive filesystem finds "itself" here
+	if info, err := os.Lstat(newPath); err == nil {
+		if oldInfo, _ := os.Lstat(oldPath); !os.SameFile(info, oldInfo) {
+			return fmt.Errorf("already exists: %s", newName)
+		}
+	}
+
+	if err := os.Rename(oldPath, newPath); err != nil {
+		return fmt.Errorf("failed to rename: %w", err)
+	}
+	return nil
+}
+
+// CheckNewName reports why name can't be created in dir, or nil if it can.
+// Names may include existing subdirectories (sub/file.go), as creation doesn't make parents.
+func CheckNewName(dir, name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if strings.ContainsFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return fmt.Errorf("contains control characters")
+	}
+	if runtime.GOOS == "windows" && strings.ContainsAny(name, `<>:"|?*`) {
+		return fmt.Errorf(`contains one of < > : " | ? *`)
+	}
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid path component %q", part)
+		}
+	}
+
+	fullPath := filepath.Join(dir, name)
+	if _, err := os.Lstat(fullPath); err == nil {
+		return fmt.Errorf("already exists")
+	}
+	parent := filepath.Dir(fullPath)
+	info, err := os.Stat(parent)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("no directory %s", filepath.Base(parent))
+	}
+	if !dirWritable(parent) {
+		return fmt.Errorf("%s is not writable", filepath.Base(parent))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Default line-count thresholds and colors for the file size indicator
+var (
+	DefaultSizeThresholds = []int{50, 100, 150, 200}
+	DefaultSizeColors     = []string{"42", "148", "226", "208", "196"} // Green → red
+)
+
+// SizeIndicator colors files by line count using configurable thresholds
+type SizeIndicator struct {
+	Thresholds []int    // Ascending line counts separating each color band
+	Colors     []string // One color per band, len(Thresholds)+1 entries
+}
+
+// NewSizeIndicator builds a size indicator from the [size_indicator] config section
+func NewSizeIndicator(cfg *Config) SizeIndicator {
+	thresholds := cfg.IntList("size_indicator.thresholds", DefaultSizeThresholds)
+	colors := cfg.StringList("size_indicator.colors", DefaultSizeColors)
+
+	// Invalid combinations fall back to the defaults rather than mis-coloring files
+	if !sort.IntsAreSorted(thresholds) || len(colors) != len(thresholds)+1 {
+		thresholds = DefaultSizeThresholds
+		colors = DefaultSizeColors
+	}
+
+	return SizeIndicator{
+		Thresholds: thresholds,
+		Colors:     colors,
+	}
+}
+
+// Color returns the color band for a file with the given line count
+func (s SizeIndicator) Color(lines int) lipgloss.Color {
+	for i, threshold := range s.Thresholds {
+		if lines <= threshold {
+			return lipgloss.Color(s.Colors[i])
+		}
+	}
+	return lipgloss.Color(s.Colors[len(s.Colors)-1])
+}
+
+// Indicator returns a colored dot representing the given line count
+func (s SizeIndicator) Indicator(lines int) string {
+	return lipgloss.NewStyle().Foreground(s.Color(lines)).Render("●")
+}
+
+// lineCountEntry is a cached line count for a file at a given modification time
+type lineCountEntry struct {
+	modTime time.Time
+	lines   int
+}
+
+// LineCountCache caches file line counts keyed by path and modification time,
+// so unchanged files aren't re-read on every tree rebuild
+type LineCountCache struct {
+	entries map[string]lineCountEntry
+}
+
+// NewLineCountCache creates an empty line count cache
+func NewLineCountCache() *LineCountCache {
+	return &LineCountCache{entries: make(map[string]lineCountEntry)}
+}
+
+// Count returns the number of lines in the file, reading it only if it changed
+func (c *LineCountCache) Count(path string, modTime time.Time) int {
+	if entry, ok := c.entries[path]; ok && entry.modTime.Equal(modTime) {
+		return entry.lines
+	}
+	lines := countFileLines(path)
+	c.entries[path] = lineCountEntry{modTime: modTime, lines: lines}
+	return lines
+}
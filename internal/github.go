@@ -2,8 +2,10 @@ package internal
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
@@ -40,67 +42,160 @@ func countFileLines(filePath string) int {
 	return lineCount
 }
 
+// GitFileStatus is the kind of uncommitted change to a file
+type GitFileStatus int
+
+const (
+	StatusModified GitFileStatus = iota
+	StatusAdded                  // Staged new file
+	StatusDeleted
+	StatusRenamed
+	StatusUntracked
+)
+
+// DiffStat holds the uncommitted change counts for a file
+type DiffStat struct {
+	Added     int
+	Removed   int
+	Untracked bool          // New file not yet known to git (line counts aren't computed)
+	Status    GitFileStatus // From git status; untracked files are StatusUntracked
+	OldPath   string        // Previous path of a renamed file
+}
+
+// numstatPath returns the path of a --numstat line, taking the new path of a
+// rename, which git writes as "old => new" or "dir/{old => new}/file"
+func numstatPath(line string) string {
+	fields := strings.SplitN(line, "\t", 3)
+	file := fields[len(fields)-1]
+	if open := strings.Index(file, "{"); open >= 0 {
+		if end := strings.Index(file[open:], "}"); end >= 0 {
+			if _, renamed, ok := strings.Cut(file[open+1:open+end], " => "); ok {
+				return filepath.ToSlash(filepath.Clean(file[:open] + renamed + file[open+end+1:]))
+			}
+		}
+	}
+	if _, renamed, ok := strings.Cut(file, " => "); ok {
+		return renamed
+	}
+	return file
+}
+
+// addPorcelainStatus classifies tracked changes from `git status --porcelain -z`
+// output, adding renamed or deleted files that numstat doesn't list by their path
+func addPorcelainStatus(stats map[string]DiffStat, output []byte) {
+	entries := strings.Split(string(output), "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		stat := stats[path]
+		switch {
+		case x == 'R' || y == 'R' || x == 'C':
+			// Renames and copies are followed by the original path
+			stat.Status = StatusRenamed
+			if i+1 < len(entries) {
+				i++
+				stat.OldPath = entries[i]
+			}
+		case x == 'D' || y == 'D':
+			stat.Status = StatusDeleted
+		case x == 'A':
+			stat.Status = StatusAdded
+		default:
+			stat.Status = StatusModified
+		}
+		stats[path] = stat
+	}
+}
+
 // GetAllGitDiffs returns a map of file paths to lines added for all changed files
 // This is much more efficient than calling git diff for each file
 func GetAllGitDiffs() map[string]int {
-	diffs := make(map[string]int)
+	return DiffCounts(GetAllGitDiffStats())
+}
 
-	// Get unstaged changes
-	cmd := exec.Command("git", "diff", "--numstat")
-	output, err := cmd.Output()
-	if err == nil {
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if line == "" {
-				continue
-			}
-			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				added, _ := strconv.Atoi(parts[0])
-				filepath := parts[2]
-				diffs[filepath] = added
-			}
+// DiffCounts converts diff stats to the lines-added map used by the tree,
+// with untracked files marked as -1
+func DiffCounts(stats map[string]DiffStat) map[string]int {
+	diffs := make(map[string]int, len(stats))
+	for path, stat := range stats {
+		if stat.Untracked {
+			diffs[path] = -1
+		} else {
+			diffs[path] = stat.Added
 		}
 	}
+	return diffs
+}
+
+// GetAllGitDiffStats returns added/removed line counts for all changed files,
+// combining staged and unstaged changes, plus untracked files. It runs in the
+// current directory, where untracked paths are reported from.
+func GetAllGitDiffStats() map[string]DiffStat {
+	return gitDiffStats("", false)
+}
+
+// GetGitDiffStatsIn returns diff stats for the repository containing dir, with
+// every path relative to that repository's root
+func GetGitDiffStatsIn(dir string) map[string]DiffStat {
+	return gitDiffStats(dir, true)
+}
+
+// gitDiffStats runs the diffs in dir ("" for the current directory). Tracked
+// paths are always relative to the repo root, untracked ones only with fullName.
+func gitDiffStats(dir string, fullName bool) map[string]DiffStat {
+	stats := make(map[string]DiffStat)
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		return commandOutput(cmd)
+	}
 
-	// Get staged changes (these add to unstaged if same file)
-	cmd = exec.Command("git", "diff", "--cached", "--numstat")
-	output, err = cmd.Output()
-	if err == nil {
-		lines := strings.Split(string(output), "\n")
-		for _, line := range lines {
-			if line == "" {
-				continue
-			}
+	// Unstaged changes, then staged changes (these add to unstaged if same file)
+	for _, args := range [][]string{{"diff", "--numstat"}, {"diff", "--cached", "--numstat"}} {
+		output, err := git(args...)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(output), "\n") {
 			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				added, _ := strconv.Atoi(parts[0])
-				filepath := parts[2]
-				// Add to existing count if file has both staged and unstaged changes
-				if existing, ok := diffs[filepath]; ok {
-					diffs[filepath] = existing + added
-				} else {
-					diffs[filepath] = added
-				}
+			if len(parts) < 3 {
+				continue
 			}
+			// Binary files report "-" for both counts
+			added, _ := strconv.Atoi(parts[0])
+			removed, _ := strconv.Atoi(parts[1])
+			file := numstatPath(line)
+			stat := stats[file]
+			stat.Added += added
+			stat.Removed += removed
+			stats[file] = stat
 		}
 	}
 
-	// Get untracked files (mark as -1 to indicate new file without expensive line counting)
-	cmd = exec.Command("git", "ls-files", "--others", "--exclude-standard")
-	output, err = cmd.Output()
-	if err == nil {
-		files := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, file := range files {
+	// Status types of tracked changes, which numstat can't tell apart
+	if output, err := git("status", "--porcelain", "-z", "--untracked-files=no"); err == nil {
+		addPorcelainStatus(stats, output)
+	}
+
+	// Get untracked files (marked rather than counted to avoid expensive I/O
+	// for potentially hundreds of untracked files)
+	args := []string{"ls-files", "--others", "--exclude-standard"}
+	if fullName {
+		// Keeps untracked paths repo-relative like the numstat output
+		args = append(args, "--full-name")
+	}
+	if output, err := git(args...); err == nil {
+		for _, file := range strings.Split(strings.TrimSpace(string(output)), "\n") {
 			if file != "" {
-				// Mark as -1 to indicate "new file" without counting lines
-				// This avoids expensive I/O for potentially hundreds of untracked files
-				diffs[file] = -1
+				stats[file] = DiffStat{Untracked: true, Status: StatusUntracked}
 			}
 		}
 	}
 
-	return diffs
+	return stats
 }
 
 // InitGitHub checks for git repo and offers to create one if needed
@@ -139,4 +234,179 @@ func InitGitHub(path string) error {
 
 	// Run the interactive Bubble Tea setup for new repo
 	return runGitHubSetup(path)
-}
\ No newline at end of file
+}
+
+// SetupGitHub runs the GitHub setup on request, even if it was declined before:
+// it creates a repo for a directory without git, adds a remote to a local-only
+// repo, or replaces a remote that's gone. It runs in the current directory.
+func SetupGitHub(path string) error {
+	clearRepoDeclined(path)
+	if !hasGitHubCLI() {
+		return fmt.Errorf("GitHub CLI (gh) not found or not logged in")
+	}
+
+	if !isInGitRepo() {
+		return runGitHubSetup(path)
+	}
+	if !hasRemote() {
+		return runGitHubSetupForLocalRepo(path)
+	}
+	if !remoteExists() {
+		return runGitHubSetupForBrokenRemote(path)
+	}
+	return fmt.Errorf("already set up, remote is %s", getRemoteURL())
+}
+
+// GetDeletedFiles returns tracked files that are missing from the working tree,
+// relative to rootPath
+func GetDeletedFiles(rootPath string) []string {
+	cmd := exec.Command("git", "ls-files", "--deleted")
+	cmd.Dir = rootPath
+	output, err := commandOutput(cmd)
+	if err != nil {
+		return nil
+	}
+
+	var deleted []string
+	for _, file := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if file != "" {
+			deleted = append(deleted, file)
+		}
+	}
+	return deleted
+}
+
+// RestoreDeletedFile restores a deleted tracked file from the index
+func RestoreDeletedFile(rootPath, relPath string) error {
+	cmd := exec.Command("git", "checkout", "--", relPath)
+	cmd.Dir = rootPath
+	if output, err := cmd.CombinedOutput(); err != nil {
+		LogError("git checkout failed", err, "path", relPath, "output", strings.TrimSpace(string(output)))
+		return fmt.Errorf("failed to restore %s: %s", relPath, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// CommitAll stages every change in the repository containing dir and commits it,
+// returning a one-line summary such as "a1b2c3d message (3 files changed)"
+func CommitAll(dir, message string) (string, error) {
+	add := exec.Command("git", "add", "-A")
+	add.Dir = dir
+	if output, err := add.CombinedOutput(); err != nil {
+		LogError("git add failed", err, "output", strings.TrimSpace(string(output)))
+		return "", fmt.Errorf("git add failed: %s", strings.TrimSpace(string(output)))
+	}
+
+	commit := exec.Command("git", "commit", "-m", message)
+	commit.Dir = dir
+	if output, err := commit.CombinedOutput(); err != nil {
+		LogError("git commit failed", err, "output", strings.TrimSpace(string(output)))
+		// "nothing to commit" and hook failures end up here
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		return "", fmt.Errorf("git commit failed: %s", lines[len(lines)-1])
+	}
+
+	show := exec.Command("git", "show", "--shortstat", "--format=%h %s", "HEAD")
+	show.Dir = dir
+	output, err := commandOutput(show)
+	if err != nil {
+		return "committed", nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	summary := lines[0]
+	if stat := strings.TrimSpace(lines[len(lines)-1]); len(lines) > 1 && stat != "" {
+		// " 3 files changed, 10 insertions(+)" -> "3 files changed"
+		stat, _, _ = strings.Cut(stat, ",")
+		summary += " (" + stat + ")"
+	}
+	return summary, nil
+}
+
+// RepoStateFingerprint cheaply summarizes HEAD and the index by reading the git
+// directory directly, without spawning git. It changes on commits, checkouts and
+// staging, but not on edits to the working tree.
+func RepoStateFingerprint(gitDir string) string {
+	var b strings.Builder
+
+	head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return ""
+	}
+	b.Write(head)
+	if ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: "); ok {
+		// The branch tip, or packed-refs when the ref isn't loose
+		if sha, err := os.ReadFile(filepath.Join(gitDir, ref)); err == nil {
+			b.Write(sha)
+		} else if info, err := os.Stat(filepath.Join(gitDir, "packed-refs")); err == nil {
+			fmt.Fprintf(&b, "packed:%d;", info.ModTime().UnixNano())
+		}
+	}
+
+	if info, err := os.Stat(filepath.Join(gitDir, "index")); err == nil {
+		fmt.Fprintf(&b, "index:%d:%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String()
+}
+
+// RemoteWebURL returns the web URL of the origin remote of the repository at
+// dir, converting SSH remotes (git@host:owner/repo.git, ssh://git@host/...) to
+// HTTPS and dropping credentials and the .git suffix
+func RemoteWebURL(dir string) (string, error) {
+	cmd := exec.Command("git", "remote", "get-url", "origin")
+	cmd.Dir = dir
+	output, err := commandOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("no origin remote")
+	}
+	remote := strings.TrimSpace(string(output))
+
+	var host, path string
+	switch {
+	case strings.HasPrefix(remote, "https://"), strings.HasPrefix(remote, "http://"), strings.HasPrefix(remote, "ssh://"), strings.HasPrefix(remote, "git://"):
+		_, rest, _ := strings.Cut(remote, "://")
+		host, path, _ = strings.Cut(rest, "/")
+		if _, after, ok := strings.Cut(host, "@"); ok {
+			host = after
+		}
+		// An SSH port isn't the web server's port
+		if h, _, ok := strings.Cut(host, ":"); ok && !strings.HasPrefix(remote, "http") {
+			host = h
+		}
+	case strings.Contains(remote, ":") && !strings.Contains(remote, "://"):
+		// scp-like syntax: git@github.com:owner/repo.git
+		host, path, _ = strings.Cut(remote, ":")
+		if _, after, ok := strings.Cut(host, "@"); ok {
+			host = after
+		}
+	default:
+		return "", fmt.Errorf("origin is not a web remote: %s", remote)
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", fmt.Errorf("can't parse origin remote: %s", remote)
+	}
+	return "https://" + host + "/" + path, nil
+}
+
+// CurrentRef returns the checked-out branch of the repository at dir, or the
+// commit hash when HEAD is detached
+func CurrentRef(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := commandOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("no commits yet")
+	}
+	if ref := strings.TrimSpace(string(output)); ref != "HEAD" {
+		return ref, nil
+	}
+
+	cmd = exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err = commandOutput(cmd)
+	if err != nil {
+		return "", fmt.Errorf("no commits yet")
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -41,6 +41,7 @@ type githubSetupModel struct {
 	height       int
 	brokenRemote bool   // True if local repo exists but remote is gone
 	oldRemoteURL string // The URL that's no longer working
+	localOnly    bool   // True if a local repo exists without any remote
 }
 
 var (
@@ -211,6 +212,11 @@ func (m githubSetupModel) View() string {
 			s.WriteString(fmt.Sprintf("GitHub account: %s\n", selectedStyle.Render(m.account)))
 			s.WriteString(fmt.Sprintf("Directory: %s\n\n", m.path))
 			s.WriteString("Create new GitHub repository to replace the missing remote?\n\n")
+		} else if m.localOnly {
+			s.WriteString(titleStyle.Render("📡 No remote configured") + "\n\n")
+			s.WriteString(fmt.Sprintf("GitHub account: %s\n", selectedStyle.Render(m.account)))
+			s.WriteString(fmt.Sprintf("Directory: %s\n\n", m.path))
+			s.WriteString("Create GitHub repository and push this local repo to it?\n\n")
 		} else {
 			s.WriteString(titleStyle.Render("📁 No git repository detected") + "\n\n")
 			s.WriteString(fmt.Sprintf("GitHub account: %s\n", selectedStyle.Render(m.account)))
@@ -257,8 +263,8 @@ func (m githubSetupModel) View() string {
 
 func (m githubSetupModel) createRepo() tea.Cmd {
 	return func() tea.Msg {
-		// If this is a broken remote case, we don't need to init
-		if !m.brokenRemote {
+		// An existing repo (broken remote or local-only) already has its history
+		if !m.brokenRemote && !m.localOnly {
 			// Initialize git repo only if it doesn't exist
 			if err := exec.Command("git", "init").Run(); err != nil {
 				return repoCreatedMsg{err: fmt.Errorf("failed to init git: %v", err)}
@@ -431,4 +437,24 @@ func runGitHubSetupForBrokenRemote(path string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// runGitHubSetupForLocalRepo offers a GitHub remote for a repo that has none,
+// keeping its existing history
+func runGitHubSetupForLocalRepo(path string) error {
+	model := newGitHubSetupModel(path)
+	model.localOnly = true
+	if len(model.accounts) == 1 {
+		model.account = model.accounts[0]
+		model.step = stepConfirmCreate
+	}
+
+	finalModel, err := tea.NewProgram(model).Run()
+	if err != nil {
+		return err
+	}
+	if setup, ok := finalModel.(githubSetupModel); ok && setup.err != nil {
+		return setup.err
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,16 +16,27 @@ type GitIgnore struct {
 
 // NewGitIgnore loads and parses .gitignore file
 func NewGitIgnore(rootPath string) *GitIgnore {
+	return loadIgnoreFile(rootPath, ".gitignore")
+}
+
+// NewVinwIgnore loads and parses .vinwignore, which uses gitignore syntax
+// to hide entries from vinw's tree without affecting git
+func NewVinwIgnore(rootPath string) *GitIgnore {
+	return loadIgnoreFile(rootPath, ".vinwignore")
+}
+
+// loadIgnoreFile parses an ignore file with gitignore syntax from rootPath
+func loadIgnoreFile(rootPath, name string) *GitIgnore {
 	gi := &GitIgnore{
 		patterns: []string{},
 		rootPath: rootPath,
 	}
 
-	// Load .gitignore file if it exists
-	gitignorePath := filepath.Join(rootPath, ".gitignore")
-	file, err := os.Open(gitignorePath)
+	// Load ignore file if it exists
+	ignorePath := filepath.Join(rootPath, name)
+	file, err := os.Open(ignorePath)
 	if err != nil {
-		// No .gitignore file
+		// No ignore file
 		return gi
 	}
 	defer file.Close()
@@ -112,4 +124,48 @@ func (gi *GitIgnore) matchPattern(path, pattern string) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
+
+// AppendToGitignore adds a pattern to rootPath/.gitignore, creating the file if
+// needed. A pattern that's already listed is left alone.
+func AppendToGitignore(rootPath, pattern string) error {
+	ignorePath := filepath.Join(rootPath, ".gitignore")
+	existing, err := os.ReadFile(ignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read .gitignore: %w", err)
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	file, err := os.OpenFile(ignorePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open .gitignore: %w", err)
+	}
+	defer file.Close()
+
+	entry := pattern + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		entry = "\n" + entry
+	}
+	if _, err := file.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to write .gitignore: %w", err)
+	}
+	return nil
+}
+
+// MatchesName reports whether an entry name equals, or matches as a glob, any of
+// the patterns. Used for the always-skip list, which applies at every depth.
+func MatchesName(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
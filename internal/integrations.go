@@ -0,0 +1,27 @@
+package internal
+
+import "os/exec"
+
+// Integration describes an optional external binary vinw relies on
+type Integration struct {
+	Binary string // Executable looked up on PATH
+	Impact string // What stops working without it
+}
+
+// optionalIntegrations lists the binaries behind vinw's optional features
+var optionalIntegrations = []Integration{
+	{Binary: "git", Impact: "diff markers and git features disabled"},
+	{Binary: "skate", Impact: "viewer pairing, themes and saved state disabled"},
+	{Binary: "gh", Impact: "GitHub repo setup disabled"},
+}
+
+// MissingIntegrations returns the optional integrations whose binaries aren't on PATH
+func MissingIntegrations() []Integration {
+	var missing []Integration
+	for _, integration := range optionalIntegrations {
+		if _, err := exec.LookPath(integration.Binary); err != nil {
+			missing = append(missing, integration)
+		}
+	}
+	return missing
+}
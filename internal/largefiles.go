@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LargeFile is a tracked file over the size threshold
+type LargeFile struct {
+	Path   string // Relative to the scanned directory
+	Size   int64
+	Binary bool
+}
+
+// FindLargeTrackedFiles returns tracked files under dir of at least threshold
+// bytes, largest first
+func FindLargeTrackedFiles(dir string, threshold int64) ([]LargeFile, error) {
+	cmd := exec.Command("git", "ls-files", "-z")
+	cmd.Dir = dir
+	output, err := commandOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked files: %w", err)
+	}
+
+	var large []LargeFile
+	for _, path := range strings.Split(string(output), "\x00") {
+		if path == "" {
+			continue
+		}
+		fullPath := filepath.Join(dir, path)
+		info, err := os.Lstat(fullPath)
+		if err != nil || !info.Mode().IsRegular() || info.Size() < threshold {
+			continue
+		}
+		large = append(large, LargeFile{Path: path, Size: info.Size(), Binary: isBinaryFile(fullPath)})
+	}
+
+	sort.Slice(large, func(i, j int) bool {
+		return large[i].Size > large[j].Size
+	})
+	return large, nil
+}
+
+// isBinaryFile guesses whether a file is binary the way git does, by looking
+// for a NUL byte near the start
+func isBinaryFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	buf := make([]byte, 8000)
+	n, _ := file.Read(buf)
+	return bytes.IndexByte(buf[:n], 0) >= 0
+}
+
+// FormatSize renders a byte count as a short human-readable size (e.g. "4.2 MB")
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// OpenExternal opens a URL or file with the platform's default handler
+// (open, xdg-open, or rundll32 on Windows) without waiting for it to exit
+func OpenExternal(target string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", target)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", target)
+	default:
+		opener := "xdg-open"
+		if _, err := exec.LookPath(opener); err != nil {
+			// WSL without xdg-utils can still reach the Windows browser
+			if _, werr := exec.LookPath("wslview"); werr != nil {
+				return fmt.Errorf("no opener found (install xdg-utils)")
+			}
+			opener = "wslview"
+		}
+		cmd = exec.Command(opener, target)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", target, err)
+	}
+	// Reap the process in the background so it doesn't linger as a zombie
+	go cmd.Wait()
+	return nil
+}
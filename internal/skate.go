@@ -1,7 +1,9 @@
 package internal
 
 import (
+	"fmt"
 	"os/exec"
+	"path/filepath"
 	"strings"
 )
 
@@ -93,4 +95,72 @@ func getGitHubAccount() string {
 		}
 	}
 	return ""
-}
\ No newline at end of file
+}
+
+// GetSessionValue reads a session-scoped value from Skate
+func GetSessionValue(name, sessionID string) string {
+	key := fmt.Sprintf("vinw-%s@%s", name, sessionID)
+	output, err := exec.Command("skate", "get", key).Output()
+	// A missing key is an error to skate, so reads are logged rather than failed
+	LogEvent("store read", "key", key, "found", err == nil)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// SetSessionValue writes a session-scoped value to Skate
+func SetSessionValue(name, sessionID, value string) {
+	key := fmt.Sprintf("vinw-%s@%s", name, sessionID)
+	err := exec.Command("skate", "set", key, value).Run()
+	LogError("store write failed", err, "key", key)
+	LogEvent("store write", "key", key, "bytes", len(value))
+}
+
+// DeleteSessionValue removes a session-scoped value from Skate
+func DeleteSessionValue(name, sessionID string) {
+	key := fmt.Sprintf("vinw-%s@%s", name, sessionID)
+	err := exec.Command("skate", "delete", key).Run()
+	LogError("store delete failed", err, "key", key)
+}
+
+// IsGitRepo checks if path is inside a git work tree
+func IsGitRepo(path string) bool {
+	cmd := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	cmd.Dir = path
+	return cmd.Run() == nil
+}
+
+// GetRepoRoot returns the top level of the git work tree containing path, or "" outside a repo
+// The result is spelled relative to path when possible, so symlinked watch paths compare cleanly
+func GetRepoRoot(path string) string {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = path
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	top := strings.TrimSpace(string(output))
+
+	// git reports the resolved path, walk up from path to find its own spelling
+	for dir := path; ; dir = filepath.Dir(dir) {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil && resolved == top {
+			return dir
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	return top
+}
+
+// GetGitDir returns the absolute git directory for the repository containing path
+func GetGitDir(path string) string {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	cmd.Dir = path
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -94,8 +94,9 @@ func NewThemeManager() *ThemeManager {
 	}
 }
 
-// NewThemeManagerWithSession creates a new theme manager with a session ID
-func NewThemeManagerWithSession(sessionID string) *ThemeManager {
+// NewThemeManagerWithSession creates a new theme manager with a session ID.
+// defaultTheme names the theme used when the session has none saved.
+func NewThemeManagerWithSession(sessionID, defaultTheme string) *ThemeManager {
 	// Try to load saved theme from Skate with session
 	savedIndex := GetSavedThemeWithSession(sessionID)
 	if savedIndex >= 0 && savedIndex < len(Themes) {
@@ -106,10 +107,11 @@ func NewThemeManagerWithSession(sessionID string) *ThemeManager {
 		}
 	}
 
-	// Default to first theme
+	// Default to the configured theme, or the first one
+	index := max(ThemeIndex(defaultTheme), 0)
 	return &ThemeManager{
-		CurrentIndex: 0,
-		Current:      Themes[0],
+		CurrentIndex: index,
+		Current:      Themes[index],
 		SessionID:    sessionID,
 	}
 }
@@ -141,6 +143,18 @@ func (tm *ThemeManager) PreviousTheme() {
 	}()
 }
 
+// SetTheme switches to the theme at index, saving and broadcasting it
+func (tm *ThemeManager) SetTheme(index int) {
+	tm.CurrentIndex = index
+	tm.Current = Themes[index]
+
+	// Run save and broadcast in single goroutine to avoid skate lock contention
+	go func() {
+		tm.SaveTheme()
+		tm.BroadcastTheme()
+	}()
+}
+
 // SaveTheme saves the current theme index to Skate
 func (tm *ThemeManager) SaveTheme() {
 	indexStr := fmt.Sprintf("%d", tm.CurrentIndex)
@@ -190,24 +204,35 @@ func GetSavedTheme() int {
 	return index
 }
 
-// GetSavedThemeWithSession retrieves the saved theme index from Skate with session
+// GetSavedThemeWithSession retrieves the saved theme index from Skate with session,
+// or -1 if the session has none
 func GetSavedThemeWithSession(sessionID string) int {
 	key := fmt.Sprintf("vinw-theme-index@%s", sessionID)
 	cmd := exec.Command("skate", "get", key)
 	output, err := cmd.Output()
 	if err != nil {
-		return 0
+		return -1
 	}
 
 	// Parse the saved index
 	indexStr := strings.TrimSpace(string(output))
 	index, err := strconv.Atoi(indexStr)
 	if err != nil {
-		return 0
+		return -1
 	}
 	return index
 }
 
+// ThemeIndex returns the index of the theme with the given name (case-insensitive), or -1
+func ThemeIndex(name string) int {
+	for i, theme := range Themes {
+		if strings.EqualFold(theme.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
 // GetCurrentTheme gets the current theme from Skate for viewer
 func GetCurrentTheme() Theme {
 	// Get theme name
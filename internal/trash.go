@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrashItem records a deleted file or directory and where it came from
+type TrashItem struct {
+	OriginalPath string
+	TrashPath    string
+	IsDir        bool
+	DeletedAt    time.Time
+}
+
+// TrashDir returns the directory trashed items are moved into for a session
+func TrashDir(sessionID string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".vinw", "trash", sessionID)
+}
+
+// MoveToTrash moves a file or directory into the session trash so it can be restored
+func MoveToTrash(fullPath, sessionID string) (TrashItem, error) {
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return TrashItem{}, fmt.Errorf("path does not exist: %s", fullPath)
+		}
+		return TrashItem{}, fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	dir := TrashDir(sessionID)
+	if dir == "" {
+		return TrashItem{}, fmt.Errorf("no home directory for trash")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return TrashItem{}, fmt.Errorf("failed to create trash: %w", err)
+	}
+
+	// Timestamp prefix keeps repeated deletes of the same name apart
+	now := time.Now()
+	trashPath := filepath.Join(dir, fmt.Sprintf("%d-%s", now.UnixNano(), filepath.Base(fullPath)))
+	if err := os.Rename(fullPath, trashPath); err != nil {
+		return TrashItem{}, fmt.Errorf("failed to move to trash: %w", err)
+	}
+
+	return TrashItem{
+		OriginalPath: fullPath,
+		TrashPath:    trashPath,
+		IsDir:        info.IsDir(),
+		DeletedAt:    now,
+	}, nil
+}
+
+// RestoreFromTrash moves a trashed item back to its original location
+func RestoreFromTrash(item TrashItem) error {
+	if _, err := os.Lstat(item.OriginalPath); err == nil {
+		return fmt.Errorf("something already exists at %s", item.OriginalPath)
+	}
+	if _, err := os.Lstat(item.TrashPath); err != nil {
+		return fmt.Errorf("trashed copy is gone: %s", item.TrashPath)
+	}
+
+	// The parent may have been deleted since
+	if err := os.MkdirAll(filepath.Dir(item.OriginalPath), 0755); err != nil {
+		return fmt.Errorf("failed to recreate parent directory: %w", err)
+	}
+	if err := os.Rename(item.TrashPath, item.OriginalPath); err != nil {
+		return fmt.Errorf("failed to restore: %w", err)
+	}
+	return nil
+}
+
+// PruneTrash permanently removes trashed items older than maxAge from every
+// session's trash, then any session directories left empty. Items are aged by
+// the timestamp prefix MoveToTrash gives them. Errors are ignored, leftovers
+// are tried again next time.
+func PruneTrash(maxAge time.Duration) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	root := filepath.Join(home, ".vinw", "trash")
+	sessions, err := os.ReadDir(root)
+	if err != nil {
+		return
+	}
+	for _, session := range sessions {
+		if !session.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, session.Name())
+		items, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, item := range items {
+			stamp, _, ok := strings.Cut(item.Name(), "-")
+			nanos, err := strconv.ParseInt(stamp, 10, 64)
+			if !ok || err != nil || time.Since(time.Unix(0, nanos)) < maxAge {
+				continue
+			}
+			os.RemoveAll(filepath.Join(dir, item.Name()))
+		}
+		// Only succeeds once the directory is empty
+		os.Remove(dir)
+	}
+}
@@ -0,0 +1,164 @@
+package internal
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// StatDirs records the modification time of each directory. A directory's mtime
+// changes when entries are added, removed or renamed, so comparing snapshots
+// detects structural changes without walking the tree. Directories that can't be
+// stat'd (e.g. deleted) get the zero time.
+func StatDirs(dirs []string) map[string]time.Time {
+	mtimes := make(map[string]time.Time, len(dirs))
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			mtimes[dir] = time.Time{}
+			continue
+		}
+		mtimes[dir] = info.ModTime()
+	}
+	return mtimes
+}
+
+// DirsChanged reports whether any directory present in both snapshots changed.
+// Directories only in one snapshot were expanded or collapsed, not modified.
+func DirsChanged(before, after map[string]time.Time) bool {
+	for dir, mtime := range after {
+		if prev, ok := before[dir]; ok && !prev.Equal(mtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// FSWatcher watches directory trees with fsnotify and signals on Changes when
+// an entry outside .git and the ignore files is created, removed, renamed or written
+type FSWatcher struct {
+	watcher       *fsnotify.Watcher
+	roots         []string
+	gitignore     *GitIgnore
+	vinwignore    *GitIgnore
+	alwaysSkip    []string
+	respectIgnore atomic.Bool
+	Changes       chan struct{}
+}
+
+// NewFSWatcher watches every directory under roots, except those named in
+// alwaysSkip or hidden by the ignore files. It fails when fsnotify is
+// unavailable or the watch limit is hit, so the caller can fall back to StatDirs.
+func NewFSWatcher(roots []string, gitignore, vinwignore *GitIgnore, alwaysSkip []string, respectIgnore bool) (*FSWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &FSWatcher{
+		watcher:    watcher,
+		roots:      roots,
+		gitignore:  gitignore,
+		vinwignore: vinwignore,
+		alwaysSkip: alwaysSkip,
+		Changes:    make(chan struct{}, 1),
+	}
+	w.respectIgnore.Store(respectIgnore)
+
+	for _, root := range roots {
+		if err := w.addTree(root); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+	go w.run()
+	return w, nil
+}
+
+// SetRespectIgnore changes whether gitignored paths are watched. Turning it off
+// adds watches for the directories that were skipped.
+func (w *FSWatcher) SetRespectIgnore(respect bool) {
+	if w.respectIgnore.Swap(respect) && !respect {
+		for _, root := range w.roots {
+			if err := w.addTree(root); err != nil {
+				LogError("watching ignored directories failed", err, "root", root)
+			}
+		}
+	}
+}
+
+// Close stops watching
+func (w *FSWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// ignored reports whether changes to a path should be left alone
+func (w *FSWatcher) ignored(path string) bool {
+	if filepath.Base(path) == ".git" || strings.Contains(path, string(filepath.Separator)+".git"+string(filepath.Separator)) {
+		return true
+	}
+	if MatchesName(w.alwaysSkip, filepath.Base(path)) {
+		return true
+	}
+	if w.respectIgnore.Load() && w.gitignore != nil && w.gitignore.IsIgnored(path) {
+		return true
+	}
+	return w.vinwignore != nil && w.vinwignore.IsIgnored(path)
+}
+
+// addTree watches dir and every directory below it that isn't ignored
+func (w *FSWatcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped, not fatal
+			if d != nil && d.IsDir() && path != dir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != dir && w.ignored(path) {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// run forwards relevant events as coalesced signals on Changes
+func (w *FSWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) || w.ignored(event.Name) {
+				continue
+			}
+			// New directories need their own watches
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := w.addTree(event.Name); err != nil {
+						LogError("watching new directory failed", err, "path", event.Name)
+					}
+				}
+			}
+			select {
+			case w.Changes <- struct{}{}:
+			default:
+				// A signal is already pending
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			LogError("file watcher error", err)
+		}
+	}
+}
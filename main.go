@@ -1,11 +1,20 @@
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +25,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/lipgloss/tree"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // Styles
@@ -27,6 +37,17 @@ var (
 	normalStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("240"))
 
+	dirStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("147"))
+
+	ghostStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("131")).
+			Strikethrough(true)
+
+	viewingStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("213")).
+			Bold(true)
+
 	headerStyle = lipgloss.NewStyle().
 			Background(lipgloss.Color("62")).
 			Foreground(lipgloss.Color("230")).
@@ -40,8 +61,28 @@ var (
 )
 
 // Messages
-type tickMsg time.Time
+type tickMsg struct{ gen int }
 type clearCopyHintMsg struct{}
+type editorFinishedMsg struct{ err error }
+type githubSetupMsg struct {
+	err    error
+	output string // What the setup wrote to stderr, i.e. why it failed
+}
+type clearStatusMsg struct{ seq int }
+type viewingFileMsg struct{ path, edited string }
+type heartbeatMsg struct{}
+type dirStatMsg struct{ mtimes map[string]time.Time }
+type fsChangedMsg struct{}
+type treeRebuildMsg struct{ label string } // Runs a toggle's rebuild after its status message was drawn
+type fsEventMsg struct{}
+type largeFilesMsg struct {
+	files []internal.LargeFile
+	err   error
+}
+type commitDoneMsg struct {
+	summary string
+	err     error
+}
 
 // Symlink support - track visited paths to prevent infinite loops
 type visitedPaths struct {
@@ -95,8 +136,29 @@ const (
 	creationNone creationMode = iota
 	creationFile
 	creationDirectory
+	creationFileAndOpen // Create a file, then send it to the viewer (and optionally an editor)
+)
+
+// Sort modes for entries within a directory
+type sortMode int
+
+const (
+	sortName      sortMode = iota // Directory order (lexical)
+	sortChanges                   // Largest uncommitted changes first
+	sortSize                      // Largest files first
+	sortModified                  // Most recently modified first
+	sortExtension                 // Grouped by extension, then by name
 )
 
+// sortModeNames are shown in the footer
+var sortModeNames = map[sortMode]string{
+	sortName:      "name",
+	sortChanges:   "changes",
+	sortSize:      "size",
+	sortModified:  "modified",
+	sortExtension: "extension",
+}
+
 // Deletion state
 type deletionState struct {
 	path      string // Full path to delete
@@ -106,286 +168,2602 @@ type deletionState struct {
 
 // Model
 type model struct {
-	rootPath       string
-	tree           *tree.Tree
-	treeString     string                 // Cached tree string
-	treeLines      []string               // Cached tree lines
-	maxLine        int                    // Cached max line number
-	viewport       viewport.Model
-	ready          bool
-	width          int
-	height         int
-	diffCache      map[string]int         // Cache for git diff results
-	lastContent    string                 // Track last content to avoid unnecessary updates
-	gitignore      *internal.GitIgnore    // GitIgnore patterns
-	respectIgnore  bool                   // Whether to respect .gitignore
-	showHidden     bool                   // Whether to show hidden files and folders
-	nestingEnabled bool                   // Whether to show nested directories (global toggle)
-	expandedDirs   map[string]bool        // Track which directories are expanded (for manual expansion)
-	selectedLine   int                    // Currently selected line in viewport
-	fileMap        map[int]string         // Map of line number to file path
-	dirMap         map[int]string         // Map of line number to directory path
-	showHelp       bool                   // Whether to show help
-	showViewer     bool                   // Whether to show viewer command popup
-	showStartup    bool                   // Whether to show startup message
-	creatingMode   creationMode           // Current creation mode (file/directory/none)
-	textInput      textinput.Model        // Text input for file/directory names
-	deletePending  *deletionState         // Pending deletion (nil if none)
-	theme          *internal.ThemeManager // Theme manager
-	sessionID      string                 // Unique session ID for this instance
-	showCopyHint   bool                   // Whether to show "Copied!" hint
-	copiedPath     string                 // Path that was copied (for display)
+	rootPath           string
+	tree               *tree.Tree
+	treeString         string   // Cached tree string
+	treeLines          []string // Cached tree lines
+	maxLine            int      // Cached max line number
+	viewport           viewport.Model
+	ready              bool
+	width              int
+	height             int
+	inGitRepo          bool                         // Whether the root is inside a git repository (git features are skipped otherwise)
+	diffCache          map[string]int               // Cache for git diff results
+	lastContent        string                       // Track last content to avoid unnecessary updates
+	gitignore          *internal.GitIgnore          // GitIgnore patterns
+	vinwignore         *internal.GitIgnore          // .vinwignore patterns (view-only exclusions)
+	respectIgnore      bool                         // Whether to respect .gitignore
+	showHidden         bool                         // Whether to show hidden files and folders
+	nestingEnabled     bool                         // Whether to show nested directories (global toggle)
+	expandedDirs       map[string]bool              // Track which directories are expanded (for manual expansion)
+	depthPrefix        bool                         // Whether z was pressed and a depth digit is expected
+	goPrefix           bool                         // Whether g was pressed and a second g is expected
+	zoomSaved          map[string]bool              // Expanded directories before zooming, nil when not zoomed
+	zoomNesting        bool                         // Nesting setting before zooming
+	selectedLine       int                          // Currently selected line in viewport
+	fileMap            map[int]string               // Map of line number to file path
+	dirMap             map[int]string               // Map of line number to directory path
+	ghostMap           map[int]string               // Map of line number to deleted (ghost) file path
+	showDeleted        bool                         // Whether to show deleted tracked files as ghost entries
+	deletedFiles       []string                     // Tracked files missing from the working tree
+	slowDirs           map[string]bool              // Directories whose reads exceeded the threshold (true: not auto-expanded, false: loaded anyway)
+	childCounts        map[string]int               // Visible entry counts of collapsed directories by relative path, cleared on refresh and filter changes
+	slowThreshold      time.Duration                // Directory read time after which auto-expansion stops (0 disables)
+	skipSymlinkDirs    bool                         // Whether symlinked directories are shown as leaves instead of traversed
+	dirsOnly           bool                         // Whether only directories are shown
+	slowSkipped        int                          // Slow directories left unread in the last build
+	depthLimited       int                          // Directories past maxDepth in the last build
+	filteredFiles      int                          // Files hidden by active filters in the last build
+	excluded           map[string]int               // Entries hidden by .vinwignore or always_skip in the last build, by source
+	recentWindow       time.Duration                // Files modified within this window are highlighted (0 = off)
+	viewingFile        string                       // Absolute path of the file the viewer reports it is showing
+	editedSeen         int64                        // Timestamp of the last "file edited" signal from the viewer that was handled
+	diffStats          map[string]internal.DiffStat // Added/removed counts per changed file
+	diffMarker         string                       // Custom diff marker format from the config
+	alwaysSkip         []string                     // Entry names or globs never shown or read, besides .git
+	hideDiffMarkers    bool                         // Whether change markers are left off file names
+	flatView           bool                         // Render one full path per line instead of tree connectors
+	wrapNames          bool                         // Wrap rows wider than the view onto continuation rows
+	wrapRows           []int                        // First screen row of each tree line while wrapping, plus the total
+	noFollowSymlinks   bool                         // --no-follow-symlinks was given, overriding the config
+	countNewFlag       bool                         // --count-new was given, overriding the config
+	countNewFiles      bool                         // Whether untracked files show their line count, (new +N)
+	sortMode           sortMode                     // How entries are ordered within each directory
+	sortReverse        bool                         // Whether the sort order is reversed
+	dirsFirst          bool                         // Whether directories are listed before files
+	typeCounts         map[string]int               // Visible files per extension from the last build
+	showTypes          bool                         // Whether the file type summary popup is showing
+	showHelp           bool                         // Whether to show help
+	showViewer         bool                         // Whether to show viewer command popup
+	missingTools       []internal.Integration       // Optional integrations unavailable on this system
+	viewerName         string                       // Command that starts the viewer, from VINW_VIEWER or viewer_command
+	viewerBinary       string                       // Absolute path of the viewer program, if it could be found
+	showStartup        bool                         // Whether to show startup message
+	creatingMode       creationMode                 // Current creation mode (file/directory/none)
+	createProblem      string                       // Why the typed name can't be created ("" = it can)
+	renaming           string                       // Relative path of the entry being renamed, empty when not renaming
+	renameErr          string                       // Why the last rename attempt failed, shown in the prompt
+	changingRoot       bool                         // Whether the change-root prompt is showing
+	changeRootErr      string                       // Why the typed root was refused, shown in the prompt
+	nameSuggestion     string                       // Available name offered when the typed one already exists
+	searching          bool                         // Whether the search prompt is active
+	searchInput        textinput.Model              // Search query input
+	searchMatches      []int                        // Lines of entries matching the current query
+	committing         bool                         // Whether the commit-all prompt is showing
+	commitInput        textinput.Model              // Message input for commit-all
+	textInput          textinput.Model              // Text input for file/directory names
+	roots              []string                     // Absolute roots of a combined view (vinw dir1 dir2), nil for a single root
+	repoRoot           string                       // Top level of the enclosing git repo, shown when it differs from the root
+	gitDir             string                       // Absolute git directory, read directly to fingerprint the repo state
+	diffFingerprint    string                       // Repo fingerprint when the diffs were last computed
+	diffCheckedAt      time.Time                    // When the diffs were last computed
+	dryRun             bool                         // Report file operations instead of performing them (--dry-run)
+	deletePending      *deletionState               // Pending deletion (nil if none)
+	theme              *internal.ThemeManager       // Theme manager
+	sessionID          string                       // Unique session ID for this instance
+	showCopyHint       bool                         // Whether to show "Copied!" hint
+	copiedPath         string                       // Path that was copied (for display)
+	statusMsg          string                       // Transient status/toast message shown in the footer
+	statusIsError      bool                         // Whether the status message is an error
+	statusSeq          int                          // Incremented per message so stale clears are ignored
+	copiedForm         string                       // Which path form was copied (absolute/relative)
+	dirSessionID       string                       // Unsuffixed session ID, keys settings remembered per directory
+	registers          map[rune]string              // Named path registers (vim-style "ay)
+	registerPrefix     bool                         // Whether a " was pressed and a register name is expected
+	pendingReg         rune                         // Register named by "x, awaiting y (yank) or p (copy out)
+	showRegisters      bool                         // Whether to show the registers popup
+	registerCursor     int                          // Selected entry in the registers popup
+	bookmarks          []string                     // Bookmarked directories relative to the watched directory
+	showBookmarks      bool                         // Whether the bookmarks popup is showing
+	bookmarkCursor     int                          // Selected entry in the bookmarks popup
+	showFileTo         bool                         // Whether the copy/move-to popup is showing
+	fileToCursor       int                          // Selected target in the copy/move-to popup
+	fileToSource       string                       // Absolute path of the file being copied or moved
+	showLargeFiles     bool                         // Whether the large files popup is showing
+	largeFiles         []internal.LargeFile         // Tracked files over the threshold, largest first
+	largeCursor        int                          // Selected entry in the large files popup
+	largeFileThreshold int64                        // Size in bytes from which tracked files count as large
+	showTrash          bool                         // Whether the trash popup is showing
+	trashCursor        int                          // Selected entry in the trash popup
+	trashed            []internal.TrashItem         // Items deleted this session, oldest first
+	config             *internal.Config             // Settings from ~/.vinw/config.toml and the project config
+	projectConfig      string                       // Project .vinw/config.toml layered over the global config ("" = none)
+	sizeIndicator      internal.SizeIndicator       // Line-count thresholds and colors for the size indicator
+	rootStack          []rootFrame                  // Previous roots when re-rooted into a subdirectory
+	refreshInterval    time.Duration                // Background refresh interval (0 = off)
+	refreshGen         int                          // Generation of the running refresh tick chain, older ticks are dropped
+	watchInterval      time.Duration                // How often visible directories are stat'd for changes (0 = off)
+	dirMtimes          map[string]time.Time         // Last stat of the visible directories
+	watcher            *internal.FSWatcher          // File system watcher, nil when falling back to stat polling
+	fsPending          bool                         // Whether a debounced rebuild is scheduled
+	confirmQuit        bool                         // Whether to confirm quitting with uncommitted changes
+	quitPending        bool                         // Whether the quit confirmation is showing
+	focusMode          bool                         // Whether header and footer are hidden to give the tree full height
+	showBreadcrumb     bool                         // Whether to show the breadcrumb bar under the header
+	fullHeaderPath     bool                         // Whether the header shows the full absolute path
+	showSizes          bool                         // Whether to prefix files with the size indicator
+	showFileSizes      bool                         // Whether to show each file's size in bytes after its name
+	lineCounts         *internal.LineCountCache     // Cached line counts for the size indicator
 }
 
-// updateTreeCache updates the cached tree string and related values
-func (m *model) updateTreeCache() {
-	m.treeString = m.tree.String()
-	m.treeLines = strings.Split(m.treeString, "\n")
-	m.maxLine = len(m.treeLines) - 1
-	if m.maxLine < 0 {
-		m.maxLine = 0
+// treeOptions bundles the display settings used when building the tree
+type treeOptions struct {
+	diffCache       map[string]int
+	gitignore       *internal.GitIgnore
+	vinwignore      *internal.GitIgnore
+	alwaysSkip      []string // Names or globs skipped regardless of ignore and hidden settings
+	diffPrefix      string   // Path of the root relative to where the diff cache paths start
+	roots           []string // Combined-view roots relative to the tree root, nil shows everything
+	respectIgnore   bool
+	nestingEnabled  bool
+	expandedDirs    map[string]bool
+	showHidden      bool
+	slowDirs        map[string]bool
+	childCounts     map[string]int // Cached entry counts for collapsed directory badges
+	slowThreshold   time.Duration
+	skipSymlinkDirs bool                         // Render symlinked directories as leaf links instead of traversing them
+	dirsOnly        bool                         // Skip file entries, showing only the directory structure
+	recentSince     time.Time                    // Highlight files modified after this time (zero = off)
+	viewingFile     string                       // Relative path of the file open in the viewer
+	diffStats       map[string]internal.DiffStat // Added/removed counts for custom markers
+	diffMarker      string                       // Custom diff marker format (empty = default (+N)/(new))
+	hideDiffMarkers bool                         // Leave markers off while still using the diff cache
+	sortMode        sortMode
+	sortReverse     bool
+	dirsFirst       bool
+	deletedFiles    map[string][]string     // Deleted files keyed by their nearest existing parent directory
+	sizeIndicator   *internal.SizeIndicator // Prefix files with a line-count indicator (nil = off)
+	fileSizes       bool                    // Show file sizes after the names
+	lineCounts      *internal.LineCountCache
+}
+
+// skipEntry reports whether an entry is filtered out of the tree: .git and the
+// always_skip names are always skipped, hidden entries (except .gitignore) unless
+// showHidden is enabled, and anything matched by .gitignore (when respected) or .vinwignore
+func (opts *treeOptions) skipEntry(fullPath, name string) bool {
+	return opts.skipReason(fullPath, name) != ""
+}
+
+// skipReason names what hides an entry: ".git", "always_skip", "hidden",
+// ".gitignore" or ".vinwignore". It's "" for entries that are shown.
+func (opts *treeOptions) skipReason(fullPath, name string) string {
+	if name == ".git" {
+		return ".git"
 	}
+	if internal.MatchesName(opts.alwaysSkip, name) {
+		return "always_skip"
+	}
+	if strings.HasPrefix(name, ".") && name != ".gitignore" && !opts.showHidden {
+		return "hidden"
+	}
+	if opts.respectIgnore && opts.gitignore != nil && opts.gitignore.IsIgnored(fullPath) {
+		return ".gitignore"
+	}
+	if opts.vinwignore != nil && opts.vinwignore.IsIgnored(fullPath) {
+		return ".vinwignore"
+	}
+	return ""
 }
 
-func (m model) Init() tea.Cmd {
-	return tick()
+// showsFile reports whether a file would appear in the tree once its parent
+// directories are expanded, checking each path component against the filters
+func (opts *treeOptions) showsFile(rootPath, relPath string) bool {
+	if opts.dirsOnly || !opts.inRoots(relPath) {
+		return false
+	}
+	fullPath := rootPath
+	for _, name := range strings.Split(relPath, string(filepath.Separator)) {
+		fullPath = filepath.Join(fullPath, name)
+		if opts.skipEntry(fullPath, name) {
+			return false
+		}
+	}
+	return true
 }
 
-func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var (
-		cmd  tea.Cmd
-		cmds []tea.Cmd
-	)
+// visibleCount returns how many of a directory's entries pass the current filters
+func (opts *treeOptions) visibleCount(fullPath string) (int, error) {
+	entries, err := os.ReadDir(fullPath)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, entry := range entries {
+		if !opts.skipEntry(filepath.Join(fullPath, entry.Name()), entry.Name()) {
+			count++
+		}
+	}
+	return count, nil
+}
 
-	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		m.width = msg.Width
-		m.height = msg.Height
+// childCount returns how many visible entries a collapsed directory holds,
+// read once and then cached until the next refresh or filter change. ok is
+// false when the directory can't be read.
+func (opts *treeOptions) childCount(fullPath, relPath string) (count int, ok bool) {
+	if count, ok := opts.childCounts[relPath]; ok {
+		return count, true
+	}
+	count, err := opts.visibleCount(fullPath)
+	if err != nil {
+		return 0, false
+	}
+	if opts.childCounts != nil {
+		opts.childCounts[relPath] = count
+	}
+	return count, true
+}
 
-		headerHeight := lipgloss.Height(m.headerView())
-		footerHeight := lipgloss.Height(m.footerView())
-		verticalMargins := headerHeight + footerHeight
+// isEmptyDir reports whether a directory has no entries visible under the current filters
+func (opts *treeOptions) isEmptyDir(fullPath string) bool {
+	count, err := opts.visibleCount(fullPath)
+	return err == nil && count == 0
+}
 
-		if !m.ready {
-			m.viewport = viewport.New(msg.Width, msg.Height-verticalMargins)
-			m.viewport.YPosition = headerHeight
-			// Rebuild tree with initial settings
-			m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-			m.updateTreeCache()
-			content := renderTreeWithSelection(m.treeString, m.selectedLine)
-			m.viewport.SetContent(content)
-			m.lastContent = content
-			m.ready = true
-		} else {
-			m.viewport.Width = msg.Width
-			m.viewport.Height = msg.Height - verticalMargins
-		}
+// diffLines looks up the cached diff count for a path relative to the tree root
+func (opts *treeOptions) diffLines(relPath string) int {
+	if opts.diffCache == nil {
+		return 0
+	}
+	return opts.diffCache[filepath.Join(opts.diffPrefix, relPath)]
+}
 
-	case tea.KeyMsg:
-		// If startup message is showing, handle special keys
-		if m.showStartup {
-			switch msg.String() {
-			case "c":
-				// Copy viewer command to clipboard
-				viewerCmd := fmt.Sprintf("vinw-viewer %s", m.sessionID)
-				copyCmd := exec.Command("pbcopy")
-				copyCmd.Stdin = strings.NewReader(viewerCmd)
-				copyCmd.Run() // Ignore errors, not all systems have pbcopy
-				m.showStartup = false
-				return m, nil
-			case "q", "ctrl+c":
-				return m, tea.Quit
-			default:
-				// Dismiss startup on any other key
-				m.showStartup = false
-				return m, nil
-			}
+// inRoots reports whether a path is shown in a combined view: a root, inside
+// one, or a directory leading to one
+func (opts *treeOptions) inRoots(relPath string) bool {
+	if opts.roots == nil {
+		return true
+	}
+	sep := string(filepath.Separator)
+	for _, root := range opts.roots {
+		if relPath == root || strings.HasPrefix(relPath, root+sep) || strings.HasPrefix(root, relPath+sep) {
+			return true
 		}
+	}
+	return false
+}
 
-		// If help is showing, any key dismisses it
-		if m.showHelp {
-			switch msg.String() {
-			case "?":
-				m.showHelp = false
-				return m, nil
-			case "q", "ctrl+c":
-				return m, tea.Quit
-			default:
-				// Dismiss help on any other key
-				m.showHelp = false
-			}
+// leadsToRoot reports whether a directory is a strict ancestor of a combined-view
+// root, these are always expanded so the roots are reachable
+func (opts *treeOptions) leadsToRoot(relPath string) bool {
+	sep := string(filepath.Separator)
+	for _, root := range opts.roots {
+		if strings.HasPrefix(root, relPath+sep) {
+			return true
 		}
+	}
+	return false
+}
 
-		// If viewer popup is showing, handle special keys
-		if m.showViewer {
-			switch msg.String() {
-			case "c":
-				// Copy viewer command to clipboard
-				viewerCmd := fmt.Sprintf("vinw-viewer %s", m.sessionID)
-				copyCmd := exec.Command("pbcopy")
-				copyCmd.Stdin = strings.NewReader(viewerCmd)
-				copyCmd.Run() // Ignore errors, not all systems have pbcopy
-				m.showViewer = false
-				return m, nil
-			case "v", "escape":
-				m.showViewer = false
-				return m, nil
-			case "q", "ctrl+c":
-				return m, tea.Quit
-			default:
-				// Dismiss viewer popup on any other key
-				m.showViewer = false
+// diffMarkerText returns the change marker appended to a file name, or "" if unchanged
+// A custom format may use {added}, {removed} and {status} (M, A, D, R or ? for untracked)
+func (opts *treeOptions) diffMarkerText(relPath string) string {
+	if opts.hideDiffMarkers {
+		return ""
+	}
+	diffLines := opts.diffLines(relPath)
+	stat := opts.diffStats[filepath.Join(opts.diffPrefix, relPath)]
+	// Pure renames have no line changes but still deserve a marker
+	if diffLines == 0 && stat.Status != internal.StatusRenamed {
+		return ""
+	}
+	if opts.diffMarker == "" {
+		switch {
+		case diffLines == -1:
+			// New untracked file (marked as -1, counted only with --count-new)
+			if stat.Added > 0 {
+				return fmt.Sprintf(" (new +%d)", stat.Added)
 			}
+			return " (new)"
+		case stat.Status == internal.StatusRenamed && diffLines > 0:
+			return fmt.Sprintf(" (renamed +%d)", diffLines)
+		case stat.Status == internal.StatusRenamed:
+			return " (renamed)"
 		}
+		return fmt.Sprintf(" (+%d)", diffLines)
+	}
 
-		// If in creation mode, handle text input
-		if m.creatingMode != creationNone {
-			switch msg.String() {
-			case "esc", "ctrl+c":
-				// Cancel creation
-				m.creatingMode = creationNone
-				m.textInput.Reset()
-				return m, nil
-			case "enter":
-				// Confirm creation
-				name := strings.TrimSpace(m.textInput.Value())
-				if name == "" {
-					// Empty name, cancel
-					m.creatingMode = creationNone
-					m.textInput.Reset()
-					return m, nil
-				}
+	status := gitStatusLetters[stat.Status]
+	if diffLines == -1 {
+		status = "?"
+	} else if stat.Added == 0 && stat.Removed == 0 {
+		// Stats unavailable, fall back to the cached count
+		stat.Added = diffLines
+	}
+	marker := strings.NewReplacer(
+		"{added}", strconv.Itoa(stat.Added),
+		"{removed}", strconv.Itoa(stat.Removed),
+		"{status}", status,
+	).Replace(opts.diffMarker)
+	return " " + marker
+}
 
-				// Determine target directory
-				targetDir := m.rootPath
-				if dirPath, ok := m.dirMap[m.selectedLine]; ok {
-					// Selected line is a directory
-					targetDir = filepath.Join(m.rootPath, dirPath)
-				} else if filePath, ok := m.fileMap[m.selectedLine]; ok {
-					// Selected line is a file, use its parent directory
-					targetDir = filepath.Join(m.rootPath, filepath.Dir(filePath))
-				}
+// gitStatusLetters are the {status} values of custom diff markers, as git status shows them
+var gitStatusLetters = map[internal.GitFileStatus]string{
+	internal.StatusModified:  "M",
+	internal.StatusAdded:     "A",
+	internal.StatusDeleted:   "D",
+	internal.StatusRenamed:   "R",
+	internal.StatusUntracked: "?",
+}
 
-				// Create file or directory
-				fullPath := filepath.Join(targetDir, name)
-				var err error
-				if m.creatingMode == creationFile {
-					err = internal.CreateFile(fullPath)
-				} else {
-					err = internal.CreateDirectory(fullPath)
-				}
+// gitStatusColors color change markers by the kind of change
+var gitStatusColors = map[internal.GitFileStatus]lipgloss.Color{
+	internal.StatusModified:  lipgloss.Color("220"), // Yellow
+	internal.StatusAdded:     lipgloss.Color("42"),  // Green
+	internal.StatusDeleted:   lipgloss.Color("196"), // Red
+	internal.StatusRenamed:   lipgloss.Color("51"),  // Cyan
+	internal.StatusUntracked: lipgloss.Color("42"),
+}
 
-				// Reset creation mode
-				m.creatingMode = creationNone
-				m.textInput.Reset()
+// diffMarkerColor returns the color of a file's change marker
+func (opts *treeOptions) diffMarkerColor(relPath string) lipgloss.Color {
+	if opts.diffLines(relPath) == -1 {
+		return gitStatusColors[internal.StatusUntracked]
+	}
+	return gitStatusColors[opts.diffStats[filepath.Join(opts.diffPrefix, relPath)].Status]
+}
 
-				if err != nil {
-					// TODO: Show error to user - for now just silently fail and rebuild tree
-					// Could add a status message field to model later
-				}
+// rootFrame remembers a previous root so it can be restored after re-rooting
+type rootFrame struct {
+	rootPath     string
+	expandedDirs map[string]bool
+	selection    string
+}
 
-				// Rebuild tree to show new file/directory
-				m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-				m.updateTreeCache()
-				newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
-				m.viewport.SetContent(newContent)
-				m.lastContent = newContent
+// sortEntries orders a directory's entries according to the sort mode, then
+// moves directories ahead of files when dirsFirst is set
+func (opts *treeOptions) sortEntries(entries []os.DirEntry, path, relativePath string) {
+	opts.sortByMode(entries, relativePath)
+	if !opts.dirsFirst {
+		return
+	}
 
-				return m, nil
-			default:
-				// Handle text input
-				var cmd tea.Cmd
-				m.textInput, cmd = m.textInput.Update(msg)
-				return m, cmd
-			}
+	// Stable, so each group keeps the sort mode's order. Lines are numbered as
+	// entries are emitted, so the maps follow the new order without extra work.
+	isDir := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			isDir[entry.Name()] = true
+		} else if isSymlink(entry) {
+			dir, _, _ := isSymlinkToDir(filepath.Join(path, entry.Name()))
+			isDir[entry.Name()] = dir
 		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return isDir[entries[i].Name()] && !isDir[entries[j].Name()]
+	})
+}
 
-		// If deletion is pending, handle confirmation
-		if m.deletePending != nil {
-			switch msg.String() {
-			case "y", "Y":
-				// Confirm deletion
-				var err error
-				if m.deletePending.isDir {
-					err = internal.DeleteDirectory(m.deletePending.path)
-				} else {
-					err = internal.DeleteFile(m.deletePending.path)
+// sortByMode orders entries by the sort mode and direction
+func (opts *treeOptions) sortByMode(entries []os.DirEntry, relativePath string) {
+	var less func(a, b os.DirEntry) bool
+	switch opts.sortMode {
+	case sortChanges:
+		// Modified files by lines changed, then new files, then directories,
+		// then clean files. Directories have no diff of their own, so they get
+		// a neutral rank rather than sinking below the clean files.
+		magnitude := func(entry os.DirEntry) int {
+			if entry.IsDir() {
+				return -1
+			}
+			lines := opts.diffLines(filepath.Join(relativePath, entry.Name()))
+			if lines == -1 {
+				return 0
+			}
+			if lines == 0 {
+				return -2
+			}
+			return lines
+		}
+		less = func(a, b os.DirEntry) bool {
+			return magnitude(a) > magnitude(b)
+		}
+	case sortSize, sortModified:
+		// Stat each entry once rather than on every comparison
+		infos := make(map[string]os.FileInfo, len(entries))
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil {
+				infos[entry.Name()] = info
+			}
+		}
+		if opts.sortMode == sortSize {
+			// Directories count as empty, their entry size means nothing
+			size := func(entry os.DirEntry) int64 {
+				if info := infos[entry.Name()]; info != nil && !info.IsDir() {
+					return info.Size()
+				}
+				return -1
+			}
+			less = func(a, b os.DirEntry) bool {
+				return size(a) > size(b)
+			}
+		} else {
+			modTime := func(entry os.DirEntry) time.Time {
+				if info := infos[entry.Name()]; info != nil {
+					return info.ModTime()
 				}
+				return time.Time{}
+			}
+			less = func(a, b os.DirEntry) bool {
+				return modTime(a).After(modTime(b))
+			}
+		}
+	case sortExtension:
+		less = func(a, b os.DirEntry) bool {
+			extA, extB := strings.ToLower(filepath.Ext(a.Name())), strings.ToLower(filepath.Ext(b.Name()))
+			if extA != extB {
+				return extA < extB
+			}
+			return a.Name() < b.Name()
+		}
+	default:
+		// Directory order is already by name
+		if !opts.sortReverse {
+			return
+		}
+		less = func(a, b os.DirEntry) bool {
+			return a.Name() < b.Name()
+		}
+	}
 
-				// Clear pending deletion
-				m.deletePending = nil
+	sort.SliceStable(entries, func(i, j int) bool {
+		if opts.sortReverse {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
 
-				if err != nil {
-					// TODO: Show error to user
-					// For now, just rebuild tree
-				}
+// gitState explains the diff markers for the footer: git missing, not a
+// repository, a clean work tree, or how many files have changes
+func (m *model) gitState() string {
+	for _, tool := range m.missingTools {
+		if tool.Binary == "git" {
+			return "git: unavailable (not installed)"
+		}
+	}
+	if !m.inGitRepo {
+		return "git: inactive (not a repo)"
+	}
+	if len(m.diffCache) == 0 {
+		return "git: clean"
+	}
+	return fmt.Sprintf("git: %d changed", len(m.diffCache))
+}
 
-				// Rebuild tree to remove deleted item
-				m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-				m.updateTreeCache()
+// sortLabel names the sort mode for the footer, marking a reversed order
+func (m *model) sortLabel() string {
+	if m.sortReverse {
+		return sortModeNames[m.sortMode] + " ↑"
+	}
+	return sortModeNames[m.sortMode]
+}
 
-				// Adjust selected line if needed
-				if m.selectedLine > m.maxLine {
-					m.selectedLine = m.maxLine
-				}
-				if m.selectedLine < 0 {
-					m.selectedLine = 0
-				}
+// treeMaps holds the line number lookups produced while building the tree
+type treeMaps struct {
+	fileMap       map[int]string
+	dirMap        map[int]string
+	ghostMap      map[int]string
+	filteredFiles int            // Files in traversed directories hidden by an active filter
+	excluded      map[string]int // Entries in traversed directories hidden by .vinwignore or always_skip, by source
+	typeCounts    map[string]int // Visible files per extension
+	slowSkipped   int            // Slow directories left unread
+	depthLimited  int            // Directories not read because they're past maxDepth
+}
 
-				newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
-				m.viewport.SetContent(newContent)
-				m.lastContent = newContent
+// countType tallies a visible file by its extension for the type summary
+func (maps *treeMaps) countType(name string) {
+	if maps.typeCounts == nil {
+		return
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" || ext == name {
+		ext = "(no ext)"
+	}
+	maps.typeCounts[ext]++
+}
 
-				return m, nil
-			case "n", "N", "esc", "ctrl+c":
-				// Cancel deletion
-				m.deletePending = nil
-				return m, nil
-			}
+// typeSummary lists visible file types by count, e.g. "120 .go"
+func (m *model) typeSummary() []string {
+	types := make([]string, 0, len(m.typeCounts))
+	for ext := range m.typeCounts {
+		types = append(types, ext)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if m.typeCounts[types[i]] != m.typeCounts[types[j]] {
+			return m.typeCounts[types[i]] > m.typeCounts[types[j]]
 		}
+		return types[i] < types[j]
+	})
 
-		switch msg.String() {
-		case "?":
-			m.showHelp = !m.showHelp
-			return m, nil
-		case "v":
-			m.showViewer = !m.showViewer
-			return m, nil
-		case "c":
-			// Copy path of selected file or directory to clipboard
-			var pathToCopy string
-			if dirPath, ok := m.dirMap[m.selectedLine]; ok {
-				// Directory selected
-				pathToCopy = filepath.Join(m.rootPath, dirPath)
-			} else if filePath, ok := m.fileMap[m.selectedLine]; ok {
-				// File selected
-				pathToCopy = filepath.Join(m.rootPath, filePath)
-			}
+	lines := make([]string, len(types))
+	for i, ext := range types {
+		lines[i] = fmt.Sprintf("%5d %s", m.typeCounts[ext], ext)
+	}
+	return lines
+}
 
-			if pathToCopy != "" {
-				copyCmd := exec.Command("pbcopy")
-				copyCmd.Stdin = strings.NewReader(pathToCopy)
-				copyCmd.Run() // Ignore errors, not all systems have pbcopy
+// treeOptions returns the model's current tree display settings
+func (m *model) treeOptions() treeOptions {
+	opts := treeOptions{
+		diffCache:       m.diffCache,
+		gitignore:       m.gitignore,
+		vinwignore:      m.vinwignore,
+		alwaysSkip:      m.alwaysSkip,
+		respectIgnore:   m.respectIgnore,
+		nestingEnabled:  m.nestingEnabled,
+		expandedDirs:    m.expandedDirs,
+		showHidden:      m.showHidden,
+		sortMode:        m.sortMode,
+		sortReverse:     m.sortReverse,
+		dirsFirst:       m.dirsFirst,
+		slowDirs:        m.slowDirs,
+		childCounts:     m.childCounts,
+		slowThreshold:   m.slowThreshold,
+		skipSymlinkDirs: m.skipSymlinkDirs,
+		dirsOnly:        m.dirsOnly,
+		diffStats:       m.diffStats,
+		diffMarker:      m.diffMarker,
+		hideDiffMarkers: m.hideDiffMarkers,
+	}
+	if m.recentWindow > 0 {
+		opts.recentSince = time.Now().Add(-m.recentWindow)
+	}
+	if m.viewingFile != "" {
+		if rel, err := filepath.Rel(m.rootPath, m.viewingFile); err == nil {
+			opts.viewingFile = rel
+		}
+	}
+	if len(m.rootStack) > 0 {
+		// Diff paths stay relative to the original root while re-rooted
+		if prefix, err := filepath.Rel(m.rootStack[0].rootPath, m.rootPath); err == nil {
+			opts.diffPrefix = prefix
+		}
+	}
+	if len(m.roots) > 0 {
+		opts.roots = m.relativeRoots()
+	}
+	if m.showDeleted {
+		opts.deletedFiles = groupDeletedFiles(m.rootPath, m.deletedFiles)
+	}
+	opts.fileSizes = m.showFileSizes
+	if m.showSizes {
+		if m.lineCounts == nil {
+			m.lineCounts = internal.NewLineCountCache()
+		}
+		opts.sizeIndicator = &m.sizeIndicator
+		opts.lineCounts = m.lineCounts
+	}
+	return opts
+}
 
-				// Show hint for 3 seconds
-				m.showCopyHint = true
-				m.copiedPath = filepath.Base(pathToCopy)
-				return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
-					return clearCopyHintMsg{}
-				})
-			}
-			return m, nil
+// refreshDiffs reloads the git diff cache, skipping git entirely outside a repo
+func (m *model) refreshDiffs() {
+	if !m.inGitRepo {
+		m.diffCache = make(map[string]int)
+		m.diffStats = nil
+		return
+	}
+	if len(m.roots) > 0 {
+		m.diffStats = combinedDiffStats(m.rootPath, m.roots)
+	} else {
+		m.diffStats = internal.GetAllGitDiffStats()
+	}
+	if m.countNewFiles {
+		m.countUntrackedLines()
+	}
+	m.diffCache = internal.DiffCounts(m.diffStats)
+	m.diffFingerprint = m.repoFingerprint()
+	m.diffCheckedAt = time.Now()
+}
+
+// countUntrackedLines fills in the added lines of untracked files, which git
+// doesn't count. Counts are cached by mtime, so only new edits are re-read.
+func (m *model) countUntrackedLines() {
+	if m.lineCounts == nil {
+		m.lineCounts = internal.NewLineCountCache()
+	}
+	for path, stat := range m.diffStats {
+		if !stat.Untracked {
+			continue
+		}
+		// Combined views key paths by the root, otherwise git reports them from the working directory
+		fullPath := path
+		if len(m.roots) > 0 {
+			fullPath = filepath.Join(m.rootPath, path)
+		}
+		info, err := os.Stat(fullPath)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		stat.Added = m.lineCounts.Count(fullPath, info.ModTime())
+		m.diffStats[path] = stat
+	}
+}
+
+// diffCacheMaxAge bounds how long cached diffs are trusted. The fingerprint
+// doesn't see edits to files that were clean; with fsnotify those arrive as
+// events, otherwise the refresh tick skips the fingerprint. This is the
+// backstop for events that were missed.
+const diffCacheMaxAge = 5 * time.Minute
+
+// refreshDiffsIfChanged re-runs the git diffs only when HEAD, the index or a
+// changed file's mtime moved since the last run, or the cache is too old
+func (m *model) refreshDiffsIfChanged() {
+	if m.gitDir != "" && m.diffFingerprint != "" && time.Since(m.diffCheckedAt) < diffCacheMaxAge {
+		if m.repoFingerprint() == m.diffFingerprint {
+			return
+		}
+	}
+	m.refreshDiffs()
+}
+
+// repoFingerprint combines the repo state with the mtimes of the files that
+// currently have changes, "" when it can't be computed
+func (m *model) repoFingerprint() string {
+	if m.gitDir == "" || len(m.roots) > 0 {
+		return ""
+	}
+	fingerprint := internal.RepoStateFingerprint(m.gitDir)
+	if fingerprint == "" {
+		return ""
+	}
+
+	// Further edits to already-changed files alter their line counts
+	repoRoot := m.repoRoot
+	if repoRoot == "" {
+		repoRoot = m.rootPath
+	}
+	paths := make([]string, 0, len(m.diffCache))
+	for path := range m.diffCache {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	b.WriteString(fingerprint)
+	for _, path := range paths {
+		if info, err := os.Stat(filepath.Join(repoRoot, path)); err == nil {
+			fmt.Fprintf(&b, "%s:%d;", path, info.ModTime().UnixNano())
+		} else {
+			fmt.Fprintf(&b, "%s:gone;", path)
+		}
+	}
+	return b.String()
+}
+
+// defaultLargeFileMB is the size from which tracked files are listed by O
+const defaultLargeFileMB = 1
+
+// trashMaxAge is how long deleted items are kept in ~/.vinw/trash before
+// being removed for good at startup
+const trashMaxAge = 7 * 24 * time.Hour
+
+// scanLargeFiles finds tracked files over the threshold without blocking the UI
+func scanLargeFiles(rootPath string, threshold int64) tea.Cmd {
+	return func() tea.Msg {
+		files, err := internal.FindLargeTrackedFiles(rootPath, threshold)
+		return largeFilesMsg{files: files, err: err}
+	}
+}
+
+// ignoreLargeFile adds a large file to the root's .gitignore. It stays tracked
+// until removed from the index, so the status says how.
+func (m *model) ignoreLargeFile(file internal.LargeFile) tea.Cmd {
+	pattern := "/" + filepath.ToSlash(file.Path)
+	if m.dryRun {
+		return m.setStatus(fmt.Sprintf("[dry-run] Would add %s to .gitignore", pattern), false)
+	}
+	if err := internal.AppendToGitignore(m.rootPath, pattern); err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+
+	// Pick up the new pattern for the tree
+	m.gitignore = internal.NewGitIgnore(m.rootPath)
+	m.showLargeFiles = false
+	m.rebuildTree()
+	m.clampSelection()
+	m.refreshViewport()
+	return m.setStatus(fmt.Sprintf("Added %s to .gitignore, run git rm --cached %s to untrack it", pattern, file.Path), false)
+}
+
+// creationTargetDir returns the directory new entries go into: the selected
+// directory, the selected file's parent, or the root
+func (m *model) creationTargetDir() string {
+	if dirPath, ok := m.dirMap[m.selectedLine]; ok {
+		return filepath.Join(m.rootPath, dirPath)
+	}
+	if filePath, ok := m.fileMap[m.selectedLine]; ok {
+		return filepath.Join(m.rootPath, filepath.Dir(filePath))
+	}
+	return m.rootPath
+}
+
+// checkCreateName validates the typed name against the target directory
+func (m *model) checkCreateName() {
+	m.createProblem = ""
+	name := strings.TrimSpace(m.textInput.Value())
+	if name == "" {
+		return
+	}
+	if err := internal.CheckNewName(m.creationTargetDir(), name); err != nil {
+		m.createProblem = err.Error()
+	}
+}
+
+// renameSelected renames the entry being renamed to newName, keeping it selected
+// and carrying over the expansion state of a renamed directory. Errors keep the
+// prompt open.
+func (m *model) renameSelected(newName string) tea.Cmd {
+	oldRel := m.renaming
+	if newName == "" || newName == filepath.Base(oldRel) {
+		m.renaming = ""
+		m.textInput.Reset()
+		return nil
+	}
+
+	newRel := filepath.Join(filepath.Dir(oldRel), newName)
+	if m.dryRun {
+		m.renaming = ""
+		m.textInput.Reset()
+		return m.setStatus(fmt.Sprintf("[dry-run] Would rename %s to %s", oldRel, newName), false)
+	}
+	if err := internal.RenameEntry(filepath.Join(m.rootPath, oldRel), newName); err != nil {
+		m.renameErr = err.Error()
+		return nil
+	}
+	m.renaming = ""
+	m.renameErr = ""
+	m.textInput.Reset()
+
+	// Expanded directories under the old name stay expanded under the new one
+	prefix := oldRel + string(filepath.Separator)
+	var moved []string
+	for dir := range m.expandedDirs {
+		if dir == oldRel || strings.HasPrefix(dir, prefix) {
+			moved = append(moved, dir)
+		}
+	}
+	for _, dir := range moved {
+		delete(m.expandedDirs, dir)
+		m.expandedDirs[newRel+strings.TrimPrefix(dir, oldRel)] = true
+	}
+
+	m.refreshDiffs()
+	m.rebuildTree()
+	if !m.selectPath(newRel) {
+		m.clampSelection()
+	}
+	m.refreshViewport()
+	m.scrollToSelection()
+	return m.setStatus(fmt.Sprintf("Renamed %s to %s", filepath.Base(oldRel), newName), false)
+}
+
+// selectFirstChange expands the tree down to the first changed file, in path
+// order, and selects it. Returns false if there are no changes to land on.
+func (m *model) selectFirstChange() bool {
+	changed := make([]string, 0, len(m.diffCache))
+	for path := range m.diffCache {
+		changed = append(changed, path)
+	}
+	sort.Strings(changed)
+
+	// Pick the target before touching the tree, so only its ancestors get
+	// expanded and the tree is rebuilt once
+	opts := m.treeOptions()
+	target := ""
+	for _, relPath := range changed {
+		// Diff paths can point outside the tree or at files deleted since
+		if _, err := os.Stat(filepath.Join(m.rootPath, relPath)); err != nil {
+			continue
+		}
+		if opts.showsFile(m.rootPath, relPath) {
+			target = relPath
+			break
+		}
+	}
+	if target == "" {
+		return false
+	}
+
+	if !m.nestingEnabled {
+		for dir := filepath.Dir(target); dir != "."; dir = filepath.Dir(dir) {
+			m.expandedDirs[dir] = true
+		}
+	}
+	m.rebuildTree()
+	return m.selectPath(target)
+}
+
+// commitAll stages every change and commits it without blocking the UI, the
+// result arrives as a commitDoneMsg
+func (m *model) commitAll(message string) tea.Cmd {
+	if m.dryRun {
+		return m.setStatus(fmt.Sprintf("[dry-run] Would stage everything and commit %q", message), false)
+	}
+	rootPath := m.rootPath
+	return tea.Batch(m.setStatus("Committing...", false), func() tea.Msg {
+		summary, err := internal.CommitAll(rootPath, message)
+		return commitDoneMsg{summary: summary, err: err}
+	})
+}
+
+// finishCommit shows the new commit in the status line and clears the
+// committed files' diff markers
+func (m *model) finishCommit(msg commitDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.setStatus(msg.err.Error(), true)
+	}
+
+	// Committed files no longer carry diff markers
+	m.refreshDiffs()
+	currentSelection := m.selectedPath()
+	m.rebuildTree()
+	if currentSelection == "" || !m.selectPath(currentSelection) {
+		m.clampSelection()
+	}
+	m.refreshViewport()
+	return m.setStatus("Committed "+msg.summary, false)
+}
+
+// relativeRoots returns the combined-view roots relative to the current tree
+// root, or nil when the tree root is inside one of them and everything shows
+func (m *model) relativeRoots() []string {
+	var roots []string
+	for _, root := range m.roots {
+		rel, err := filepath.Rel(root, m.rootPath)
+		if err == nil && !strings.HasPrefix(rel, "..") {
+			// Re-rooted inside this root
+			return nil
+		}
+		if rel, err := filepath.Rel(m.rootPath, root); err == nil && !strings.HasPrefix(rel, "..") {
+			roots = append(roots, rel)
+		}
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+	return roots
+}
+
+// combinedDiffStats merges the diffs of each root's repository, keyed relative
+// to rootPath. Roots sharing a repository only query it once.
+func combinedDiffStats(rootPath string, roots []string) map[string]internal.DiffStat {
+	stats := make(map[string]internal.DiffStat)
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		if !internal.IsGitRepo(root) {
+			continue
+		}
+		repo := internal.GetRepoRoot(root)
+		if seen[repo] {
+			continue
+		}
+		seen[repo] = true
+		for path, stat := range internal.GetGitDiffStatsIn(root) {
+			rel, err := filepath.Rel(rootPath, filepath.Join(repo, path))
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			stats[rel] = stat
+		}
+	}
+	return stats
+}
+
+// commonParent returns the deepest directory containing all of the given absolute paths
+func commonParent(paths []string) string {
+	parent := paths[0]
+	for _, path := range paths[1:] {
+		for parent != filepath.Dir(parent) {
+			if rel, err := filepath.Rel(parent, path); err == nil && !strings.HasPrefix(rel, "..") {
+				break
+			}
+			parent = filepath.Dir(parent)
+		}
+	}
+	return parent
+}
+
+// rebuildTree rebuilds the tree and line maps from the current settings
+func (m *model) rebuildTree() {
+	if m.showDeleted && m.inGitRepo {
+		m.deletedFiles = internal.GetDeletedFiles(m.rootPath)
+	}
+	var maps treeMaps
+	start := time.Now()
+	m.tree, maps = buildTreeWithMaps(m.rootPath, m.treeOptions())
+	internal.LogEvent("tree rebuilt", "root", m.rootPath, "files", len(maps.fileMap), "dirs", len(maps.dirMap), "duration_ms", time.Since(start).Milliseconds())
+	m.fileMap, m.dirMap, m.ghostMap = maps.fileMap, maps.dirMap, maps.ghostMap
+	m.filteredFiles, m.excluded = maps.filteredFiles, maps.excluded
+	m.typeCounts = maps.typeCounts
+	m.slowSkipped, m.depthLimited = maps.slowSkipped, maps.depthLimited
+	m.updateTreeCache()
+
+	// The footer grows a line while parts of the tree are filtered or truncated
+	m.resizeViewport()
+}
+
+// selectedPath returns the relative path of the file or directory at the selected line
+func (m *model) selectedPath() string {
+	if f, ok := m.fileMap[m.selectedLine]; ok {
+		return f
+	}
+	if d, ok := m.dirMap[m.selectedLine]; ok {
+		return d
+	}
+	return ""
+}
+
+// selectPath moves the selection to the line showing relPath, returning false if it isn't visible
+func (m *model) selectPath(relPath string) bool {
+	for line, file := range m.fileMap {
+		if file == relPath {
+			m.selectedLine = line
+			return true
+		}
+	}
+	for line, dir := range m.dirMap {
+		if dir == relPath {
+			m.selectedLine = line
+			return true
+		}
+	}
+	for line, ghost := range m.ghostMap {
+		if ghost == relPath {
+			m.selectedLine = line
+			return true
+		}
+	}
+	return false
+}
+
+// scrollToSelection adjusts the viewport offset so the selected line is visible
+func (m *model) scrollToSelection() {
+	top, bottom := m.selectionRows()
+	if top < m.viewport.YOffset {
+		m.viewport.SetYOffset(top)
+	} else if m.viewport.Height > 0 && bottom >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(bottom - m.viewport.Height + 1)
+	}
+}
+
+// selectionRows returns the first and last screen rows of the selected line,
+// which differ from the line number once long names wrap
+func (m *model) selectionRows() (int, int) {
+	if !m.wrapNames || m.selectedLine+1 >= len(m.wrapRows) {
+		return m.selectedLine, m.selectedLine
+	}
+	return m.wrapRows[m.selectedLine], m.wrapRows[m.selectedLine+1] - 1
+}
+
+// pushRoot re-roots the tree at a subdirectory, remembering the current root
+func (m *model) pushRoot(dirRel string) {
+	m.rootStack = append(m.rootStack, rootFrame{
+		rootPath:     m.rootPath,
+		expandedDirs: m.expandedDirs,
+		selection:    dirRel,
+	})
+
+	// Carry over expansions inside the new root
+	expanded := make(map[string]bool)
+	prefix := dirRel + string(filepath.Separator)
+	for dir := range m.expandedDirs {
+		if strings.HasPrefix(dir, prefix) {
+			expanded[strings.TrimPrefix(dir, prefix)] = true
+		}
+	}
+
+	m.rootPath = filepath.Join(m.rootPath, dirRel)
+	clear(m.childCounts)
+	m.expandedDirs = expanded
+	m.selectedLine = 0
+	m.rebuildTree()
+	m.refreshViewport()
+	m.viewport.GotoTop()
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+// changeRoot switches the tree to another directory as if vinw had been started
+// there: ignore files, git state, config, view settings and bookmarks all follow
+// the new root. The session ID stays, so the paired viewer remains connected.
+func (m *model) changeRoot(path string) error {
+	path = expandHome(path)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(m.rootPath, path)
+	}
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("no such directory: %s", shortenPath(path))
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a directory: %s", shortenPath(path))
+	}
+	// Git commands run in the working directory, as they do at startup
+	if err := os.Chdir(path); err != nil {
+		return fmt.Errorf("can't enter %s: %w", shortenPath(path), err)
+	}
+
+	m.rootPath = path
+	m.roots = nil
+	m.rootStack = nil
+	m.zoomSaved = nil
+	m.expandedDirs = make(map[string]bool)
+	m.slowDirs = make(map[string]bool)
+	m.childCounts = make(map[string]int)
+	m.dirMtimes = nil
+	m.selectedLine = 0
+	m.dirSessionID = generateSessionID(path)
+	m.gitignore = internal.NewGitIgnore(path)
+	m.vinwignore = internal.NewVinwIgnore(path)
+	m.inGitRepo = internal.IsGitRepo(path)
+	m.repoRoot, m.gitDir = "", ""
+	if m.inGitRepo {
+		m.repoRoot = internal.GetRepoRoot(path)
+		m.gitDir = internal.GetGitDir(path)
+	}
+
+	config, projectConfig := internal.LoadConfigFor(path)
+	m.projectConfig = projectConfig
+	m.applyConfig(config)
+	m.bookmarks = loadBookmarks(m.dirSessionID)
+	m.refreshDiffs()
+
+	// Watch the new tree instead of the old one
+	if m.watcher != nil {
+		m.watcher.Close()
+		m.watcher = nil
+		if watcher, err := internal.NewFSWatcher([]string{path}, m.gitignore, m.vinwignore, m.alwaysSkip, m.respectIgnore); err == nil {
+			m.watcher = watcher
+		} else {
+			internal.LogError("file watcher unavailable, polling instead", err)
+		}
+	}
+
+	m.rebuildTree()
+	m.refreshViewport()
+	m.viewport.GotoTop()
+	go m.theme.BroadcastTheme()
+	internal.LogEvent("root changed", "root", path)
+	return nil
+}
+
+// popRoot restores the previous root and selects the directory that was rooted
+func (m *model) popRoot() {
+	if len(m.rootStack) == 0 {
+		return
+	}
+	frame := m.rootStack[len(m.rootStack)-1]
+	m.rootStack = m.rootStack[:len(m.rootStack)-1]
+
+	m.rootPath = frame.rootPath
+	clear(m.childCounts)
+	m.expandedDirs = frame.expandedDirs
+	m.rebuildTree()
+	if !m.selectPath(frame.selection) {
+		m.selectedLine = 0
+	}
+	m.clampSelection()
+	m.refreshViewport()
+	m.scrollToSelection()
+}
+
+// expandToDepth shows the tree exactly depth levels deep, collapsing everything below
+func (m *model) expandToDepth(depth int) {
+	selection := m.selectedPath()
+
+	// Expand one level per rebuild so the builder's own filtering decides what's visible
+	m.nestingEnabled = false
+	m.expandedDirs = make(map[string]bool)
+	m.rebuildTree()
+	for level := 1; level < depth; level++ {
+		added := false
+		for _, dir := range m.dirMap {
+			if strings.Count(dir, string(filepath.Separator))+1 == level && !m.expandedDirs[dir] {
+				m.expandedDirs[dir] = true
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+		m.rebuildTree()
+	}
+
+	// Fall back to the nearest visible ancestor of the old selection
+	for selection != "" && selection != "." && !m.selectPath(selection) {
+		selection = filepath.Dir(selection)
+	}
+	m.clampSelection()
+	m.refreshViewport()
+	m.scrollToSelection()
+}
+
+// expandAll expands every directory the tree can show, walking the filesystem
+// once with the builder's filters. Manual expansion stays in effect (nesting off).
+func (m *model) expandAll() int {
+	selection := m.selectedPath()
+	opts := m.treeOptions()
+	sep := string(filepath.Separator)
+	expanded := make(map[string]bool)
+	filepath.WalkDir(m.rootPath, func(path string, d fs.DirEntry, err error) error {
+		if path == m.rootPath {
+			return err
+		}
+		if err != nil || !d.IsDir() {
+			// Unreadable directories simply stay collapsed
+			return nil
+		}
+		rel, _ := filepath.Rel(m.rootPath, path)
+		if opts.skipEntry(path, d.Name()) || !opts.inRoots(rel) || strings.Count(rel, sep) >= maxDepth {
+			return filepath.SkipDir
+		}
+		expanded[rel] = true
+		return nil
+	})
+
+	m.nestingEnabled = false
+	m.expandedDirs = expanded
+	m.rebuildTree()
+	if selection == "" || !m.selectPath(selection) {
+		m.clampSelection()
+	}
+	m.refreshViewport()
+	m.scrollToSelection()
+	return len(expanded)
+}
+
+// collapseAll collapses every directory, keeping the selection on its top-level ancestor
+func (m *model) collapseAll() {
+	selection := m.selectedPath()
+	m.nestingEnabled = false
+	m.expandedDirs = make(map[string]bool)
+	m.rebuildTree()
+	for selection != "" && selection != "." && !m.selectPath(selection) {
+		selection = filepath.Dir(selection)
+	}
+	m.clampSelection()
+	m.refreshViewport()
+	m.scrollToSelection()
+}
+
+// zoom collapses every directory off the selection's ancestry, remembering the
+// expansion state so a second zoom restores it
+func (m *model) zoom() {
+	selection := m.selectedPath()
+	if m.zoomSaved != nil {
+		m.expandedDirs = m.zoomSaved
+		m.nestingEnabled = m.zoomNesting
+		m.zoomSaved = nil
+	} else {
+		m.zoomSaved = m.expandedDirs
+		m.zoomNesting = m.nestingEnabled
+		m.nestingEnabled = false
+		m.expandedDirs = make(map[string]bool)
+		// A selected directory stays open so its contents remain in view
+		dir := selection
+		if _, isFile := m.fileMap[m.selectedLine]; isFile {
+			dir = filepath.Dir(selection)
+		}
+		for ; dir != "." && dir != ""; dir = filepath.Dir(dir) {
+			m.expandedDirs[dir] = true
+		}
+	}
+
+	m.rebuildTree()
+	if selection == "" || !m.selectPath(selection) {
+		m.clampSelection()
+	}
+	m.refreshViewport()
+	m.scrollToSelection()
+}
+
+// keepSelectionRow scrolls the viewport so the selected line sits at the given
+// screen row, falling back to just keeping it visible
+func (m *model) keepSelectionRow(row int) {
+	if row < 0 || row >= m.viewport.Height {
+		m.scrollToSelection()
+		return
+	}
+	top, _ := m.selectionRows()
+	offset := top - row
+	if offset < 0 {
+		offset = 0
+	}
+	m.viewport.SetYOffset(offset)
+}
+
+// clampSelection keeps the selected line within the tree bounds
+func (m *model) clampSelection() {
+	if m.selectedLine > m.maxLine {
+		m.selectedLine = m.maxLine
+	}
+	if m.selectedLine < 0 {
+		m.selectedLine = 0
+	}
+}
+
+// refreshViewport re-renders the cached tree lines with the current selection
+func (m *model) refreshViewport() {
+	newContent := m.treeContent()
+	m.viewport.SetContent(newContent)
+	m.lastContent = newContent
+}
+
+// restoreTrashed moves a trashed item back and removes it from the trash list
+func (m *model) restoreTrashed(i int) tea.Cmd {
+	item := m.trashed[i]
+	if m.dryRun {
+		return m.setStatus(fmt.Sprintf("[dry-run] Would restore %s", shortenPath(item.OriginalPath)), false)
+	}
+	if err := internal.RestoreFromTrash(item); err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+
+	m.trashed = append(m.trashed[:i], m.trashed[i+1:]...)
+	if m.trashCursor >= len(m.trashed) && m.trashCursor > 0 {
+		m.trashCursor--
+	}
+	if len(m.trashed) == 0 {
+		m.showTrash = false
+	}
+
+	m.rebuildTree()
+	m.clampSelection()
+	m.refreshViewport()
+	return m.setStatus(fmt.Sprintf("Restored %s", shortenPath(item.OriginalPath)), false)
+}
+
+// setStatus shows a transient message in the footer, cleared after a few seconds
+func (m *model) setStatus(msg string, isError bool) tea.Cmd {
+	m.statusMsg = msg
+	m.statusIsError = isError
+	m.statusSeq++
+	seq := m.statusSeq
+	return tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
+		return clearStatusMsg{seq: seq}
+	})
+}
+
+// copySelectedPath copies the selected entry's absolute or root-relative path
+// to the clipboard and shows the copy hint
+func (m *model) copySelectedPath(relative bool) tea.Cmd {
+	relPath := m.selectedPath()
+	if relPath == "" {
+		return nil
+	}
+
+	if relative {
+		return m.copyWithHint(relPath, "relative", relPath)
+	}
+	fullPath := filepath.Join(m.rootPath, relPath)
+	return m.copyWithHint(fullPath, "absolute", filepath.Base(fullPath))
+}
+
+// copyWithHint copies text to the clipboard and shows the copy hint for 3 seconds,
+// or an error in the status line if the copy failed
+func (m *model) copyWithHint(text, form, shown string) tea.Cmd {
+	if err := internal.CopyToClipboard(text); err != nil {
+		return m.setStatus("Copy failed: "+err.Error(), true)
+	}
+
+	m.showCopyHint = true
+	m.copiedForm = form
+	m.copiedPath = shown
+	return tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
+		return clearCopyHintMsg{}
+	})
+}
+
+// matchLines returns the sorted lines of tree entries whose relative path
+// contains the query, ignoring case
+func (m *model) matchLines(query string) []int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	var lines []int
+	for _, entries := range []map[int]string{m.fileMap, m.dirMap} {
+		for line, path := range entries {
+			if strings.Contains(strings.ToLower(path), query) {
+				lines = append(lines, line)
+			}
+		}
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// copyRepoPath copies the selected path relative to the git repo root, the
+// form git commands and GitHub URLs use
+func (m *model) copyRepoPath() tea.Cmd {
+	relPath := m.selectedPath()
+	if relPath == "" {
+		return nil
+	}
+	if m.repoRoot == "" {
+		return m.setStatus("Not in a git repository", true)
+	}
+
+	repoPath, err := filepath.Rel(m.repoRoot, filepath.Join(m.rootPath, relPath))
+	if err != nil {
+		return m.setStatus(fmt.Sprintf("Can't resolve repo path: %v", err), true)
+	}
+	repoPath = filepath.ToSlash(repoPath)
+
+	return m.copyWithHint(repoPath, "repo", repoPath)
+}
+
+// openRemote opens the origin remote's web page in the browser, or with selection
+// the selected file (blob) or directory (tree) at the current branch
+func (m *model) openRemote(selection bool) tea.Cmd {
+	if m.repoRoot == "" {
+		return m.setStatus("Not in a git repository", true)
+	}
+	webURL, err := internal.RemoteWebURL(m.repoRoot)
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+
+	if selection {
+		repoPath, err := filepath.Rel(m.repoRoot, filepath.Join(m.rootPath, m.selectedPath()))
+		if err != nil {
+			return m.setStatus(fmt.Sprintf("Can't resolve repo path: %v", err), true)
+		}
+		ref, err := internal.CurrentRef(m.repoRoot)
+		if err != nil {
+			return m.setStatus(err.Error(), true)
+		}
+		kind := "blob"
+		if _, isFile := m.fileMap[m.selectedLine]; !isFile {
+			kind = "tree"
+		}
+		webURL += "/" + kind + "/" + ref
+		if repoPath != "." {
+			var segments []string
+			for _, segment := range strings.Split(filepath.ToSlash(repoPath), "/") {
+				segments = append(segments, url.PathEscape(segment))
+			}
+			webURL += "/" + strings.Join(segments, "/")
+		}
+	}
+
+	if err := internal.OpenExternal(webURL); err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	return m.setStatus("Opened "+webURL, false)
+}
+
+// breadcrumbParts returns the root name followed by each component of the selected path
+func (m *model) breadcrumbParts() []string {
+	parts := []string{filepath.Base(m.rootPath)}
+	if relPath := m.selectedPath(); relPath != "" {
+		parts = append(parts, strings.Split(relPath, string(filepath.Separator))...)
+	}
+	return parts
+}
+
+// selectedBreadcrumb returns the selected entry's location as a breadcrumb,
+// e.g. "project > src > components > Button.go"
+func (m *model) selectedBreadcrumb() string {
+	return strings.Join(m.breadcrumbParts(), " > ")
+}
+
+// fitBreadcrumb joins breadcrumb parts, eliding ancestors after the root
+// until the result fits within width
+func fitBreadcrumb(parts []string, width int) string {
+	const sep = " > "
+	breadcrumb := strings.Join(parts, sep)
+	if lipgloss.Width(breadcrumb) <= width || len(parts) <= 2 {
+		return breadcrumb
+	}
+
+	// Drop ancestors closest to the root first, keeping the root and selection
+	for elided := 1; elided < len(parts)-1; elided++ {
+		kept := append([]string{parts[0], "…"}, parts[1+elided:]...)
+		breadcrumb = strings.Join(kept, sep)
+		if lipgloss.Width(breadcrumb) <= width {
+			return breadcrumb
+		}
+	}
+
+	// Still too wide: keep the tail end, which is the most specific part
+	runes := []rune(breadcrumb)
+	for len(runes) > 1 && lipgloss.Width(string(runes)) > width-1 {
+		runes = runes[1:]
+	}
+	return "…" + string(runes)
+}
+
+// copyBreadcrumb copies the selected entry's breadcrumb to the clipboard
+func (m *model) copyBreadcrumb() tea.Cmd {
+	if m.selectedPath() == "" {
+		return nil
+	}
+	breadcrumb := m.selectedBreadcrumb()
+
+	return m.copyWithHint(breadcrumb, "breadcrumb", breadcrumb)
+}
+
+// yankToRegister stores the selected entry's absolute path in a named register
+func (m *model) yankToRegister(reg rune) tea.Cmd {
+	relPath := m.selectedPath()
+	if relPath == "" {
+		return nil
+	}
+	if m.registers == nil {
+		m.registers = make(map[rune]string)
+	}
+	m.registers[reg] = filepath.Join(m.rootPath, relPath)
+	saveRegisters(m.sessionID, m.registers)
+
+	m.showCopyHint = true
+	m.copiedForm = fmt.Sprintf("to register %c", reg)
+	m.copiedPath = filepath.Base(relPath)
+	return tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
+		return clearCopyHintMsg{}
+	})
+}
+
+// copyRegister copies a named register's path to the clipboard
+func (m *model) copyRegister(reg rune) tea.Cmd {
+	path, ok := m.registers[reg]
+	if !ok {
+		return nil
+	}
+
+	return m.copyWithHint(path, fmt.Sprintf("from register %c", reg), filepath.Base(path))
+}
+
+// fileToTarget is a directory offered by the copy/move-to popup
+type fileToTarget struct {
+	label string // Register name or bookmark marker shown before the path
+	path  string
+}
+
+// fileToTargets returns the bookmarked directories, then the registers that
+// hold directories in alphabetical order
+func (m *model) fileToTargets() []fileToTarget {
+	var targets []fileToTarget
+	for _, bookmark := range m.bookmarks {
+		path := filepath.Join(m.watchRoot(), bookmark)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			targets = append(targets, fileToTarget{label: "' ", path: path})
+		}
+	}
+	for _, reg := range m.registerNames() {
+		if info, err := os.Stat(m.registers[reg]); err == nil && info.IsDir() {
+			targets = append(targets, fileToTarget{label: fmt.Sprintf("\"%c", reg), path: m.registers[reg]})
+		}
+	}
+	return targets
+}
+
+// fileTo copies or moves the pending file into a target directory
+func (m *model) fileTo(dstDir string, move bool) tea.Cmd {
+	src := m.fileToSource
+	m.showFileTo = false
+	m.fileToSource = ""
+
+	verb, done := "copy", "Copied"
+	if move {
+		verb, done = "move", "Moved"
+	}
+	if m.dryRun {
+		return m.setStatus(fmt.Sprintf("[dry-run] Would %s %s to %s", verb, filepath.Base(src), shortenPath(dstDir)), false)
+	}
+
+	var err error
+	if move {
+		_, err = internal.MoveItem(src, dstDir)
+	} else {
+		_, err = internal.CopyFile(src, dstDir)
+	}
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+
+	currentSelection := m.selectedPath()
+	m.rebuildTree()
+	if currentSelection == "" || !m.selectPath(currentSelection) {
+		m.clampSelection()
+	}
+	m.refreshViewport()
+	return m.setStatus(fmt.Sprintf("%s %s to %s", done, filepath.Base(src), shortenPath(dstDir)), false)
+}
+
+// registerNames returns the names of filled registers in alphabetical order
+func (m *model) registerNames() []rune {
+	var names []rune
+	for reg := 'a'; reg <= 'z'; reg++ {
+		if _, ok := m.registers[reg]; ok {
+			names = append(names, reg)
+		}
+	}
+	return names
+}
+
+// loadRegisters reads the session's named registers from Skate
+func loadRegisters(sessionID string) map[rune]string {
+	registers := make(map[rune]string)
+	for _, line := range strings.Split(internal.GetSessionValue("registers", sessionID), "\n") {
+		name, path, ok := strings.Cut(line, "\t")
+		if !ok || len(name) != 1 {
+			continue
+		}
+		registers[rune(name[0])] = path
+	}
+	return registers
+}
+
+// saveRegisters writes the session's named registers to Skate
+func saveRegisters(sessionID string, registers map[rune]string) {
+	var lines []string
+	for reg := 'a'; reg <= 'z'; reg++ {
+		if path, ok := registers[reg]; ok {
+			lines = append(lines, fmt.Sprintf("%c\t%s", reg, path))
+		}
+	}
+	go internal.SetSessionValue("registers", sessionID, strings.Join(lines, "\n"))
+}
+
+// watchRoot returns the directory vinw was started in, even while re-rooted
+func (m *model) watchRoot() string {
+	if len(m.rootStack) > 0 {
+		return m.rootStack[0].rootPath
+	}
+	return m.rootPath
+}
+
+// toggleBookmark bookmarks the selected directory, or removes its bookmark
+func (m *model) toggleBookmark() tea.Cmd {
+	dirPath, ok := m.dirMap[m.selectedLine]
+	if !ok {
+		return m.setStatus("Select a directory to bookmark", true)
+	}
+	rel, err := filepath.Rel(m.watchRoot(), filepath.Join(m.rootPath, dirPath))
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+
+	if i := slices.Index(m.bookmarks, rel); i >= 0 {
+		m.bookmarks = slices.Delete(m.bookmarks, i, i+1)
+		saveBookmarks(m.dirSessionID, m.bookmarks)
+		return m.setStatus("Removed bookmark "+rel, false)
+	}
+	m.bookmarks = append(m.bookmarks, rel)
+	saveBookmarks(m.dirSessionID, m.bookmarks)
+	return m.setStatus("Bookmarked "+rel, false)
+}
+
+// jumpToBookmark expands the way to a bookmarked directory and selects it
+func (m *model) jumpToBookmark(bookmark string) tea.Cmd {
+	fullPath := filepath.Join(m.watchRoot(), bookmark)
+	if info, err := os.Stat(fullPath); err != nil || !info.IsDir() {
+		return m.setStatus(bookmark+" no longer exists", true)
+	}
+	rel, err := filepath.Rel(m.rootPath, fullPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return m.setStatus(bookmark+" is outside the current root (< to go back)", true)
+	}
+
+	if !m.nestingEnabled {
+		for dir := rel; dir != "."; dir = filepath.Dir(dir) {
+			m.expandedDirs[dir] = true
+		}
+	}
+	m.rebuildTree()
+	if !m.selectPath(rel) {
+		m.clampSelection()
+		m.refreshViewport()
+		return m.setStatus(bookmark+" is hidden by the current filters", true)
+	}
+	m.refreshViewport()
+	m.scrollToSelection()
+	return nil
+}
+
+// loadBookmarks reads the bookmarks remembered for a directory from Skate
+func loadBookmarks(dirSessionID string) []string {
+	var bookmarks []string
+	for _, line := range strings.Split(internal.GetSessionValue("bookmarks", dirSessionID), "\n") {
+		if line != "" {
+			bookmarks = append(bookmarks, line)
+		}
+	}
+	return bookmarks
+}
+
+// saveBookmarks writes a directory's bookmarks to Skate
+func saveBookmarks(dirSessionID string, bookmarks []string) {
+	go internal.SetSessionValue("bookmarks", dirSessionID, strings.Join(bookmarks, "\n"))
+}
+
+// applyConfig applies settings from the config file, then the view settings
+// saved for this directory on top of the config's defaults
+func (m *model) applyConfig(config *internal.Config) {
+	m.config = config
+	m.diffMarker = config.String("diff_marker", "")
+	m.alwaysSkip = config.StringList("always_skip", nil)
+	clear(m.childCounts)
+	m.confirmQuit = config.Bool("confirm_quit", false)
+	m.refreshInterval = refreshIntervalSetting(config)
+	m.watchInterval = watchIntervalSetting(config)
+	m.slowThreshold = slowDirThresholdSetting(config)
+	m.skipSymlinkDirs = m.noFollowSymlinks || !config.Bool("follow_symlinks", true)
+	m.countNewFiles = m.countNewFlag || config.Bool("count_new_files", false)
+	m.wrapNames = config.Bool("wrap_names", false)
+	m.sizeIndicator = internal.NewSizeIndicator(config)
+	m.largeFileThreshold = int64(config.Int("large_file_mb", defaultLargeFileMB)) << 20
+	m.viewerName = viewerNameSetting(config)
+	m.viewerBinary = resolveViewerBinary(m.viewerName)
+
+	m.applyViewSettings(fmt.Sprintf("sort=%s,reverse=%t,dirsfirst=%t,hidden=%t,markers=%t", config.String("sort", "name"), config.Bool("sort_reverse", false), config.Bool("dirs_first", true), config.Bool("show_hidden", false), config.Bool("show_diff_markers", true)))
+	m.applyViewSettings(internal.GetSessionValue("view-settings", m.dirSessionID))
+}
+
+// applyViewSettings applies view settings stored as comma-separated key=value
+// pairs (sort, reverse, dirsfirst, hidden, folders, markers), ignoring unknown keys and values
+func (m *model) applyViewSettings(settings string) {
+	for _, pair := range strings.Split(settings, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "sort":
+			for mode, name := range sortModeNames {
+				if name == value {
+					m.sortMode = mode
+				}
+			}
+		case "reverse":
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				m.sortReverse = enabled
+			}
+		case "dirsfirst":
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				m.dirsFirst = enabled
+			}
+		case "hidden":
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				m.showHidden = enabled
+			}
+		case "folders":
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				m.dirsOnly = enabled
+			}
+		case "markers":
+			if enabled, err := strconv.ParseBool(value); err == nil {
+				m.hideDiffMarkers = !enabled
+			}
+		}
+	}
+}
+
+// saveViewSettings remembers the view settings for this directory in Skate
+// The session ID is derived from the absolute path, so each directory keeps its own
+func (m *model) saveViewSettings() {
+	settings := fmt.Sprintf("sort=%s,reverse=%t,dirsfirst=%t,hidden=%t,folders=%t,markers=%t", sortModeNames[m.sortMode], m.sortReverse, m.dirsFirst, m.showHidden, m.dirsOnly, !m.hideDiffMarkers)
+	go internal.SetSessionValue("view-settings", m.dirSessionID, settings)
+}
+
+// sendToViewer writes the file to Skate for the paired viewer to pick up
+func (m *model) sendToViewer(fullPath string) {
+	key := fmt.Sprintf("vinw-current-file@%s", m.sessionID)
+	cmd := exec.Command("skate", "set", key, fullPath)
+	internal.LogError("sending file to viewer failed", cmd.Run(), "path", fullPath)
+}
+
+// openInEditor suspends the TUI and opens the file in $VISUAL or $EDITOR
+func openInEditor(fullPath string) tea.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return nil
+	}
+
+	c := exec.Command(args[0], append(args[1:], fullPath)...)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return editorFinishedMsg{err}
+	})
+}
+
+// runGitHubSetup suspends the TUI and runs the GitHub setup flow in a child
+// vinw, so it gets the terminal to itself. Its stderr is kept for the status line.
+func (m *model) runGitHubSetup() tea.Cmd {
+	exe, err := os.Executable()
+	if err != nil {
+		return m.setStatus("Can't find the vinw executable: "+err.Error(), true)
+	}
+	var stderr bytes.Buffer
+	root := m.watchRoot()
+	c := exec.Command(exe, "--github-setup", root)
+	c.Dir = root
+	c.Stderr = &stderr
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		return githubSetupMsg{err: err, output: strings.TrimSpace(stderr.String())}
+	})
+}
+
+// groupDeletedFiles groups deleted files under their nearest parent directory
+// that still exists, so files inside removed directories remain reachable
+func groupDeletedFiles(rootPath string, deletedFiles []string) map[string][]string {
+	grouped := make(map[string][]string)
+	for _, file := range deletedFiles {
+		parent := filepath.Dir(file)
+		for parent != "." {
+			if info, err := os.Stat(filepath.Join(rootPath, parent)); err == nil && info.IsDir() {
+				break
+			}
+			parent = filepath.Dir(parent)
+		}
+		if parent == "." {
+			parent = ""
+		}
+		grouped[parent] = append(grouped[parent], file)
+	}
+	return grouped
+}
+
+// flattenTreeLines swaps the tree connectors for each entry's full relative path,
+// keeping one entry per line so the line maps, navigation and file operations still apply
+func (m *model) flattenTreeLines(lines []string) []string {
+	flat := make([]string, len(lines))
+	for i, line := range lines {
+		if ghostPath, ok := m.ghostMap[i]; ok {
+			flat[i] = ghostStyle.Render(ghostPath) + normalStyle.Render(" (deleted)")
+			continue
+		}
+		path, ok := m.fileMap[i]
+		if !ok {
+			path, ok = m.dirMap[i]
+		}
+		if !ok {
+			// Root and warning lines are kept as they are
+			flat[i] = line
+			continue
+		}
+
+		// The entry's own rendering (name, markers) follows the connectors
+		entry := strings.TrimLeft(line, "│├└─ ")
+		if dir := filepath.Dir(path); dir != "." {
+			entry = normalStyle.Render(dir+string(filepath.Separator)) + entry
+		}
+		flat[i] = entry
+	}
+	return flat
+}
+
+// updateTreeCache updates the cached tree string and related values
+func (m *model) updateTreeCache() {
+	m.treeString = m.tree.String()
+	m.treeLines = strings.Split(m.treeString, "\n")
+	if m.flatView {
+		m.treeLines = m.flattenTreeLines(m.treeLines)
+		m.treeString = strings.Join(m.treeLines, "\n")
+	}
+	m.maxLine = len(m.treeLines) - 1
+	if m.maxLine < 0 {
+		m.maxLine = 0
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(tick(m.refreshInterval, m.refreshGen), m.pollViewing(), heartbeat(), m.statWatch(), m.waitForFSEvent())
+}
+
+// newEditSignal parses the viewer's "file edited" signal ("<unix nanos>\t<path>")
+// and returns the path if the signal hasn't been handled yet
+func (m *model) newEditSignal(signal string) (string, bool) {
+	stamp, path, ok := strings.Cut(signal, "\t")
+	if !ok {
+		return "", false
+	}
+	at, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil || at <= m.editedSeen {
+		return "", false
+	}
+	m.editedSeen = at
+	return path, true
+}
+
+// editedLabel names an edited file relative to the root, with its change count
+func (m *model) editedLabel(path string) string {
+	rel, err := filepath.Rel(m.rootPath, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.Base(path)
+	}
+	opts := m.treeOptions()
+	if count := opts.diffLines(rel); count > 0 {
+		return fmt.Sprintf("%s (+%d)", rel, count)
+	}
+	return rel
+}
+
+// viewingPollInterval is how often vinw asks which file the viewer is showing
+const viewingPollInterval = 2 * time.Second
+
+// pollViewing reads back the file the paired viewer is displaying, if Skate is available
+func (m model) pollViewing() tea.Cmd {
+	for _, tool := range m.missingTools {
+		if tool.Binary == "skate" {
+			return nil
+		}
+	}
+	sessionID := m.sessionID
+	return tea.Tick(viewingPollInterval, func(t time.Time) tea.Msg {
+		return viewingFileMsg{
+			path:   internal.GetSessionValue("viewing", sessionID),
+			edited: internal.GetSessionValue("edited", sessionID),
+		}
+	})
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var (
+		cmd  tea.Cmd
+		cmds []tea.Cmd
+	)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+		headerHeight, footerHeight := m.chromeHeights()
+		verticalMargins := headerHeight + footerHeight
+
+		if !m.ready {
+			m.viewport = viewport.New(msg.Width, msg.Height-verticalMargins)
+			m.viewport.YPosition = headerHeight
+			// Rebuild tree with initial settings
+			m.rebuildTree()
+			content := m.treeContent()
+			m.viewport.SetContent(content)
+			m.lastContent = content
+			m.ready = true
+			// The selection may start below the fold (--changes)
+			m.scrollToSelection()
+		} else {
+			m.viewport.Width = msg.Width
+			m.viewport.Height = msg.Height - verticalMargins
+			if m.wrapNames {
+				// Rows wrap at the new width
+				m.refreshViewport()
+				m.scrollToSelection()
+			}
+		}
+
+	case tea.KeyMsg:
+		// If startup message is showing, handle special keys
+		if m.showStartup {
+			switch msg.String() {
+			case "c", "C":
+				// Copy viewer command to clipboard, C with the binary's full path
+				m.showStartup = false
+				return m, m.copyWithHint(m.viewerCommand(msg.String() == "C"), "viewer command", m.viewerProgram())
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				// Dismiss startup on any other key
+				m.showStartup = false
+				return m, nil
+			}
+		}
+
+		// If help is showing, any key dismisses it
+		if m.showHelp {
+			switch msg.String() {
+			case "?":
+				m.showHelp = false
+				return m, nil
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				// Dismiss help on any other key
+				m.showHelp = false
+			}
+		}
+
+		// Any key closes the file type summary
+		if m.showTypes {
+			m.showTypes = false
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+
+		// If viewer popup is showing, handle special keys
+		if m.showViewer {
+			switch msg.String() {
+			case "c", "C":
+				// Copy viewer command to clipboard, C with the binary's full path
+				m.showViewer = false
+				return m, m.copyWithHint(m.viewerCommand(msg.String() == "C"), "viewer command", m.viewerProgram())
+			case "v", "escape":
+				m.showViewer = false
+				return m, nil
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				// Dismiss viewer popup on any other key
+				m.showViewer = false
+			}
+		}
+
+		// If changing the root, handle text input
+		if m.changingRoot {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.changingRoot = false
+				m.textInput.Reset()
+				return m, nil
+			case "enter":
+				path := strings.TrimSpace(m.textInput.Value())
+				if path == "" {
+					m.changingRoot = false
+					return m, nil
+				}
+				wasWatching := m.watcher != nil
+				if err := m.changeRoot(path); err != nil {
+					m.changeRootErr = err.Error()
+					return m, nil
+				}
+				m.changingRoot = false
+				m.textInput.Reset()
+				return m, tea.Batch(m.setStatus("Root: "+shortenPath(m.rootPath), false), m.restartWatch(wasWatching))
+			default:
+				var cmd tea.Cmd
+				m.textInput, cmd = m.textInput.Update(msg)
+				m.changeRootErr = ""
+				return m, cmd
+			}
+		}
+
+		// If renaming, handle text input
+		if m.renaming != "" {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.renaming = ""
+				m.renameErr = ""
+				m.textInput.Reset()
+				return m, nil
+			case "enter":
+				return m, m.renameSelected(strings.TrimSpace(m.textInput.Value()))
+			default:
+				var cmd tea.Cmd
+				m.textInput, cmd = m.textInput.Update(msg)
+				m.renameErr = ""
+				return m, cmd
+			}
+		}
+
+		// If in creation mode, handle text input
+		if m.creatingMode != creationNone {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				// Cancel creation
+				m.creatingMode = creationNone
+				m.nameSuggestion = ""
+				m.textInput.Reset()
+				return m, nil
+			case "tab":
+				// Accept the suggested name, leaving it editable
+				if m.nameSuggestion != "" {
+					m.textInput.SetValue(m.nameSuggestion)
+					m.textInput.CursorEnd()
+					m.nameSuggestion = ""
+					m.checkCreateName()
+				}
+				return m, nil
+			case "enter":
+				// Confirm creation
+				name := strings.TrimSpace(m.textInput.Value())
+				if name == "" {
+					// Empty name, cancel
+					m.creatingMode = creationNone
+					m.textInput.Reset()
+					return m, nil
+				}
+
+				// Create file or directory
+				targetDir := m.creationTargetDir()
+				fullPath := filepath.Join(targetDir, name)
+				mode := m.creatingMode
+
+				// On a collision keep the prompt open and offer the next free name
+				if _, err := os.Lstat(fullPath); err == nil {
+					m.nameSuggestion = internal.SuggestAvailableName(targetDir, name)
+					return m, nil
+				}
+				m.nameSuggestion = ""
+
+				// The indicator already says what's wrong with the name
+				if m.createProblem != "" {
+					return m, nil
+				}
+
+				// In dry-run mode, only report what would happen
+				if m.dryRun {
+					m.creatingMode = creationNone
+					m.textInput.Reset()
+					kind := "file"
+					if mode == creationDirectory {
+						kind = "directory"
+					}
+					return m, m.setStatus(fmt.Sprintf("[dry-run] Would create %s %s", kind, shortenPath(fullPath)), false)
+				}
+
+				var err error
+				if mode == creationDirectory {
+					err = internal.CreateDirectory(fullPath)
+				} else {
+					err = internal.CreateFile(fullPath)
+				}
+
+				// Reset creation mode
+				m.creatingMode = creationNone
+				m.textInput.Reset()
+
+				var statusCmd tea.Cmd
+				if err != nil {
+					statusCmd = m.setStatus(err.Error(), true)
+				} else {
+					statusCmd = m.setStatus("Created "+shortenPath(fullPath), false)
+				}
+
+				// Make sure a file we're about to open is visible in the tree
+				relPath, _ := filepath.Rel(m.rootPath, fullPath)
+				if mode == creationFileAndOpen && err == nil && !m.nestingEnabled {
+					if parent := filepath.Dir(relPath); parent != "." {
+						m.expandedDirs[parent] = true
+					}
+				}
+
+				// Rebuild tree to show new file/directory
+				m.rebuildTree()
+
+				var cmd tea.Cmd
+				if mode == creationFileAndOpen && err == nil {
+					// Select the new file and hand it to the viewer
+					m.selectPath(relPath)
+					m.scrollToSelection()
+					m.sendToViewer(fullPath)
+					if m.config.Bool("open_new_in_editor", false) {
+						cmd = openInEditor(fullPath)
+					}
+				}
+
+				newContent := m.treeContent()
+				m.viewport.SetContent(newContent)
+				m.lastContent = newContent
+
+				return m, tea.Batch(statusCmd, cmd)
+			default:
+				// Handle text input, a stale suggestion no longer applies once edited
+				var cmd tea.Cmd
+				prev := m.textInput.Value()
+				m.textInput, cmd = m.textInput.Update(msg)
+				if m.textInput.Value() != prev {
+					m.nameSuggestion = ""
+					m.checkCreateName()
+				}
+				return m, cmd
+			}
+		}
+
+		// If searching, keys go to the query
+		if m.searching {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.searching = false
+				m.searchMatches = nil
+				return m, nil
+			case "enter":
+				// Jump to the next match after the selection, wrapping around
+				m.searching = false
+				if len(m.searchMatches) > 0 {
+					from := m.selectedLine
+					m.selectedLine = m.searchMatches[0]
+					for _, line := range m.searchMatches {
+						if line > from {
+							m.selectedLine = line
+							break
+						}
+					}
+					m.refreshViewport()
+					m.scrollToSelection()
+				}
+				return m, nil
+			default:
+				var cmd tea.Cmd
+				m.searchInput, cmd = m.searchInput.Update(msg)
+				m.searchMatches = m.matchLines(m.searchInput.Value())
+				return m, cmd
+			}
+		}
+
+		// If the commit prompt is showing, handle text input
+		if m.committing {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.committing = false
+				return m, nil
+			case "enter":
+				message := strings.TrimSpace(m.commitInput.Value())
+				if message == "" {
+					return m, nil
+				}
+				m.committing = false
+				return m, m.commitAll(message)
+			default:
+				var cmd tea.Cmd
+				m.commitInput, cmd = m.commitInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		// If quit confirmation is showing, handle it
+		if m.quitPending {
+			switch msg.String() {
+			case "y", "Y", "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.quitPending = false
+				return m, nil
+			}
+		}
+
+		// If deletion is pending, handle confirmation
+		if m.deletePending != nil {
+			switch msg.String() {
+			case "y", "Y":
+				// In dry-run mode, only report what would happen
+				if m.dryRun {
+					target := shortenPath(m.deletePending.path)
+					m.deletePending = nil
+					return m, m.setStatus(fmt.Sprintf("[dry-run] Would delete %s", target), false)
+				}
+
+				// Confirm deletion, moving to the session trash so it can be restored
+				var statusCmd tea.Cmd
+				item, err := internal.MoveToTrash(m.deletePending.path, m.sessionID)
+				if err == nil {
+					m.trashed = append(m.trashed, item)
+					statusCmd = m.setStatus(fmt.Sprintf("Moved %s to trash (ctrl+z to undo)", shortenPath(item.OriginalPath)), false)
+				} else {
+					// Trash unavailable (e.g. another filesystem), delete permanently
+					internal.LogError("trash unavailable, deleting permanently", err)
+					if m.deletePending.isDir {
+						err = internal.DeleteDirectory(m.deletePending.path)
+					} else {
+						err = internal.DeleteFile(m.deletePending.path)
+					}
+					if err != nil {
+						statusCmd = m.setStatus(err.Error(), true)
+					} else {
+						statusCmd = m.setStatus(fmt.Sprintf("Trash unavailable, deleted %s permanently (can't undo)", shortenPath(m.deletePending.path)), false)
+					}
+				}
+
+				// Clear pending deletion
+				m.deletePending = nil
+
+				// Rebuild tree to remove deleted item
+				m.rebuildTree()
+
+				// Adjust selected line if needed
+				if m.selectedLine > m.maxLine {
+					m.selectedLine = m.maxLine
+				}
+				if m.selectedLine < 0 {
+					m.selectedLine = 0
+				}
+
+				newContent := m.treeContent()
+				m.viewport.SetContent(newContent)
+				m.lastContent = newContent
+
+				return m, statusCmd
+			case "n", "N", "esc", "ctrl+c":
+				// Cancel deletion
+				m.deletePending = nil
+				return m, nil
+			}
+		}
+
+		// If registers popup is showing, handle its keys
+		if m.showRegisters {
+			names := m.registerNames()
+			switch msg.String() {
+			case "j", "down":
+				if m.registerCursor < len(names)-1 {
+					m.registerCursor++
+				}
+			case "k", "up":
+				if m.registerCursor > 0 {
+					m.registerCursor--
+				}
+			case "enter":
+				if m.registerCursor < len(names) {
+					m.showRegisters = false
+					return m, m.copyRegister(names[m.registerCursor])
+				}
+			case "x":
+				// Clear the selected register
+				if m.registerCursor < len(names) {
+					delete(m.registers, names[m.registerCursor])
+					saveRegisters(m.sessionID, m.registers)
+					if m.registerCursor > 0 && m.registerCursor >= len(names)-1 {
+						m.registerCursor--
+					}
+				}
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.showRegisters = false
+			}
+			return m, nil
+		}
+
+		// If the bookmarks popup is showing, handle its keys
+		if m.showBookmarks {
+			switch msg.String() {
+			case "j", "down":
+				if m.bookmarkCursor < len(m.bookmarks)-1 {
+					m.bookmarkCursor++
+				}
+			case "k", "up":
+				if m.bookmarkCursor > 0 {
+					m.bookmarkCursor--
+				}
+			case "enter":
+				if m.bookmarkCursor < len(m.bookmarks) {
+					m.showBookmarks = false
+					return m, m.jumpToBookmark(m.bookmarks[m.bookmarkCursor])
+				}
+			case "x":
+				// Remove the selected bookmark
+				if m.bookmarkCursor < len(m.bookmarks) {
+					m.bookmarks = slices.Delete(m.bookmarks, m.bookmarkCursor, m.bookmarkCursor+1)
+					saveBookmarks(m.dirSessionID, m.bookmarks)
+					if m.bookmarkCursor > 0 && m.bookmarkCursor >= len(m.bookmarks) {
+						m.bookmarkCursor--
+					}
+				}
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.showBookmarks = false
+			}
+			return m, nil
+		}
+
+		// If the copy/move-to popup is showing, handle its keys
+		if m.showFileTo {
+			targets := m.fileToTargets()
+			switch msg.String() {
+			case "j", "down":
+				if m.fileToCursor < len(targets)-1 {
+					m.fileToCursor++
+				}
+			case "k", "up":
+				if m.fileToCursor > 0 {
+					m.fileToCursor--
+				}
+			case "enter", "M":
+				// enter copies, M moves
+				if m.fileToCursor < len(targets) {
+					return m, m.fileTo(targets[m.fileToCursor].path, msg.String() == "M")
+				}
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.showFileTo = false
+				m.fileToSource = ""
+			}
+			return m, nil
+		}
+
+		// If the large files popup is showing, handle its keys
+		if m.showLargeFiles {
+			switch msg.String() {
+			case "j", "down":
+				if m.largeCursor < len(m.largeFiles)-1 {
+					m.largeCursor++
+				}
+			case "k", "up":
+				if m.largeCursor > 0 {
+					m.largeCursor--
+				}
+			case "enter", "i":
+				if m.largeCursor < len(m.largeFiles) {
+					return m, m.ignoreLargeFile(m.largeFiles[m.largeCursor])
+				}
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.showLargeFiles = false
+			}
+			return m, nil
+		}
+
+		// If trash popup is showing, handle its keys
+		if m.showTrash {
+			switch msg.String() {
+			case "j", "down":
+				if m.trashCursor < len(m.trashed)-1 {
+					m.trashCursor++
+				}
+			case "k", "up":
+				if m.trashCursor > 0 {
+					m.trashCursor--
+				}
+			case "enter", "r":
+				if m.trashCursor < len(m.trashed) {
+					return m, m.restoreTrashed(m.trashCursor)
+				}
+			case "q", "ctrl+c":
+				return m, tea.Quit
+			default:
+				m.showTrash = false
+			}
+			return m, nil
+		}
+
+		// z followed by a digit sets the tree depth
+		if m.depthPrefix {
+			m.depthPrefix = false
+			if key := msg.String(); len(key) == 1 && key[0] >= '1' && key[0] <= '9' {
+				depth := int(key[0] - '0')
+				m.expandToDepth(depth)
+				return m, m.setStatus(fmt.Sprintf("Showing %d level(s)", depth), false)
+			}
+			return m, nil
+		}
+
+		// g sequences: gg jumps to the top, gh/gb open the remote, anything else cancels
+		if m.goPrefix {
+			m.goPrefix = false
+			switch msg.String() {
+			case "g":
+				m.selectedLine = 0
+				m.refreshViewport()
+				m.viewport.GotoTop()
+			case "h":
+				return m, m.openRemote(false)
+			case "b":
+				return m, m.openRemote(true)
+			}
+			return m, nil
+		}
+
+		// Handle vim-style register sequences: "ay yanks, "ap copies register out
+		if m.registerPrefix {
+			m.registerPrefix = false
+			if key := []rune(msg.String()); len(key) == 1 && key[0] >= 'a' && key[0] <= 'z' {
+				m.pendingReg = key[0]
+			}
+			return m, nil
+		}
+		if m.pendingReg != 0 {
+			reg := m.pendingReg
+			m.pendingReg = 0
+			switch msg.String() {
+			case "y":
+				return m, m.yankToRegister(reg)
+			case "p":
+				return m, m.copyRegister(reg)
+			}
+			return m, nil
+		}
+
+		switch msg.String() {
+		case "f":
+			// Toggle focus mode, giving the tree the full terminal height
+			m.focusMode = !m.focusMode
+			m.resizeViewport()
+			m.scrollToSelection()
+			return m, nil
+		case "Z":
+			// Zoom into the selection's branch, or restore the previous expansion
+			m.zoom()
+			return m, nil
+		case "z":
+			// Start a depth sequence (z1-z9)
+			m.depthPrefix = true
+			return m, nil
+		case "E":
+			// Expand every directory
+			count := m.expandAll()
+			return m, m.setStatus(fmt.Sprintf("Expanded %d directories", count), false)
+		case "X":
+			// Collapse every directory (C already copies the relative path)
+			m.collapseAll()
+			return m, m.setStatus("Collapsed all directories", false)
+		case "g":
+			// Start a gg sequence
+			m.goPrefix = true
+			return m, nil
+		case "ctrl+d", "ctrl+u":
+			// Move the selection and the view by half a screen, like vim
+			half := max(m.viewport.Height/2, 1)
+			if msg.String() == "ctrl+u" {
+				half = -half
+			}
+			m.selectedLine = min(max(m.selectedLine+half, 0), m.maxLine)
+			m.refreshViewport()
+			m.viewport.SetYOffset(m.viewport.YOffset + half)
+			m.scrollToSelection()
+			return m, nil
+		case "G":
+			// Jump to the last line
+			m.selectedLine = m.maxLine
+			m.refreshViewport()
+			m.viewport.GotoBottom()
+			return m, nil
+		case "\"":
+			// Start a register sequence
+			m.registerPrefix = true
+			return m, nil
+		case "b":
+			// Bookmark the selected directory (again to remove)
+			return m, m.toggleBookmark()
+		case "'":
+			// Show bookmarks
+			m.showBookmarks = true
+			m.bookmarkCursor = 0
+			return m, nil
+		case "@":
+			// Show named registers
+			m.showRegisters = true
+			m.registerCursor = 0
+			return m, nil
+		case "M":
+			// Copy or move the selected file into a bookmarked directory or one held in a register
+			if filePath, ok := m.fileMap[m.selectedLine]; ok {
+				m.showFileTo = true
+				m.fileToCursor = 0
+				m.fileToSource = filepath.Join(m.rootPath, filePath)
+			}
+			return m, nil
+		case "ctrl+z":
+			// Undo the most recent delete
+			if len(m.trashed) == 0 {
+				return m, m.setStatus("Nothing to undo", false)
+			}
+			return m, m.restoreTrashed(len(m.trashed) - 1)
+		case "U":
+			// Show items deleted this session
+			m.showTrash = true
+			m.trashCursor = len(m.trashed) - 1
+			if m.trashCursor < 0 {
+				m.trashCursor = 0
+			}
+			return m, nil
+		case "?":
+			m.showHelp = !m.showHelp
+			return m, nil
+		case "I":
+			// Show the file type summary for what's currently in the tree
+			m.showTypes = true
+			return m, nil
+		case "v":
+			m.showViewer = !m.showViewer
+			return m, nil
+		case "c":
+			// Copy absolute path of selected file or directory to clipboard
+			return m, m.copySelectedPath(false)
+		case "C":
+			// Copy path relative to the root directory
+			return m, m.copySelectedPath(true)
+		case "O":
+			// List oversized tracked files, scanned in the background
+			if !m.inGitRepo {
+				return m, m.setStatus("Not a git repository", true)
+			}
+			return m, tea.Batch(m.setStatus("Scanning tracked files...", false), scanLargeFiles(m.rootPath, m.largeFileThreshold))
+		case "W":
+			// Save work: stage everything and commit with a message
+			if !m.inGitRepo {
+				return m, m.setStatus("Not a git repository", true)
+			}
+			m.committing = true
+			m.commitInput = textinput.New()
+			m.commitInput.Prompt = "commit all: "
+			m.commitInput.Placeholder = "message"
+			m.commitInput.CharLimit = 255
+			m.commitInput.Focus()
+			return m, nil
+		case "/":
+			// Search entries in the current tree
+			m.searching = true
+			m.searchMatches = nil
+			m.searchInput = textinput.New()
+			m.searchInput.Prompt = "/"
+			m.searchInput.Placeholder = "search"
+			m.searchInput.CharLimit = 255
+			m.searchInput.Focus()
+			return m, nil
+		case "ctrl+r":
+			// Reload the config files and re-render with their settings
+			wasWatching := m.watchInterval > 0
+			config, projectConfig := internal.LoadConfigFor(m.rootPath)
+			m.projectConfig = projectConfig
+			m.applyConfig(config)
+			if index := internal.ThemeIndex(config.String("theme", "")); index >= 0 && index != m.theme.CurrentIndex {
+				m.theme.SetTheme(index)
+			}
+
+			currentSelection := m.selectedPath()
+			m.rebuildTree()
+			if currentSelection == "" || !m.selectPath(currentSelection) {
+				m.clampSelection()
+			}
+			m.resizeViewport()
+			m.refreshViewport()
+			m.scrollToSelection()
+
+			reloaded := "Reloaded " + shortenPath(internal.ConfigPath())
+			if m.projectConfig != "" {
+				reloaded += " + " + shortenPath(m.projectConfig)
+			}
+			cmds := []tea.Cmd{m.setStatus(reloaded, false)}
+			// Restart background refresh with the new interval, a tick still
+			// pending from the old chain is dropped by its generation
+			m.refreshGen++
+			cmds = append(cmds, tick(m.refreshInterval, m.refreshGen))
+			if !wasWatching {
+				cmds = append(cmds, m.statWatch())
+			}
+			return m, tea.Batch(cmds...)
+		case "V":
+			// Copy the session ID for launching the viewer
+			return m, m.copyWithHint(m.sessionID, "session ID", m.sessionID)
+		case "Y":
+			// Copy path relative to the git repo root
+			return m, m.copyRepoPath()
+		case "B":
+			// Copy breadcrumb of the selected location
+			return m, m.copyBreadcrumb()
 		case "r":
 			// Manual git refresh (fast - updates diff markers only, no tree rebuild)
-			m.diffCache = internal.GetAllGitDiffs()
+			m.refreshDiffs()
 			// Re-render tree with updated diff cache but same structure
-			newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+			newContent := m.treeContent()
 			m.viewport.SetContent(newContent)
 			m.lastContent = newContent
 			return m, nil
 		case "R":
 			// Full refresh (slow - rebuilds entire tree + git diff)
-			m.diffCache = internal.GetAllGitDiffs()
+			clear(m.childCounts)
+			m.refreshDiffs()
 
 			// Remember current selection
 			var currentSelection string
@@ -396,8 +2774,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// Rebuild entire tree
-			m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-			m.updateTreeCache()
+			m.rebuildTree()
 
 			// Try to maintain selection
 			newSelectedLine := 0
@@ -428,11 +2805,18 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.selectedLine = newSelectedLine
 
 			// Update viewport
-			newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+			newContent := m.treeContent()
 			m.viewport.SetContent(newContent)
 			m.lastContent = newContent
 			return m, nil
-		case "q", "ctrl+c":
+		case "q":
+			// Optionally confirm before quitting with uncommitted changes
+			if m.confirmQuit && len(m.diffCache) > 0 {
+				m.quitPending = true
+				return m, nil
+			}
+			return m, tea.Quit
+		case "ctrl+c":
 			return m, tea.Quit
 		case "t":
 			// Next theme
@@ -445,42 +2829,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "i":
 			// Toggle gitignore respect
 			m.respectIgnore = !m.respectIgnore
-
-			// Remember the currently selected file if one exists
-			var currentFile string
-			if f, ok := m.fileMap[m.selectedLine]; ok {
-				currentFile = f
-			}
-
-			// Rebuild tree with new ignore setting
-			m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-			m.updateTreeCache()
-
-			// Try to find the same file in the new map
-			newSelectedLine := 0
-			if currentFile != "" {
-				for line, file := range m.fileMap {
-					if file == currentFile {
-						newSelectedLine = line
-						break
-					}
-				}
-			}
-
-			// Ensure selected line is within bounds
-			if newSelectedLine > m.maxLine {
-				newSelectedLine = m.maxLine
+			clear(m.childCounts)
+			if m.watcher != nil {
+				m.watcher.SetRespectIgnore(m.respectIgnore)
 			}
-			if newSelectedLine < 0 {
-				newSelectedLine = 0
-			}
-			m.selectedLine = newSelectedLine
 
-			// Update viewport with new selection
-			newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
-			m.viewport.SetContent(newContent)
-			m.lastContent = newContent
-			return m, nil
+			return m, m.rebuildAfterToggle(ignoreToggleLabel(m.respectIgnore))
 		case "n":
 			// Toggle directory nesting
 			m.nestingEnabled = !m.nestingEnabled
@@ -491,51 +2845,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.expandedDirs = make(map[string]bool)
 			}
 
-			// Remember the currently selected file if one exists
-			var currentFile string
-			if f, ok := m.fileMap[m.selectedLine]; ok {
-				currentFile = f
-			}
-
-			// Rebuild tree with new nesting setting
-			m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-			m.updateTreeCache()
-
-			// Try to find the same file in the new map
-			newSelectedLine := 0
-			if currentFile != "" {
-				for line, file := range m.fileMap {
-					if file == currentFile {
-						newSelectedLine = line
-						break
-					}
-				}
-			}
-
-			// Ensure selected line is within bounds
-			if newSelectedLine > m.maxLine {
-				newSelectedLine = m.maxLine
-			}
-			if newSelectedLine < 0 {
-				newSelectedLine = 0
-			}
-			m.selectedLine = newSelectedLine
-
-			// Update viewport with new selection
-			newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
-			m.viewport.SetContent(newContent)
-			m.lastContent = newContent
-			return m, nil
+			return m, m.rebuildAfterToggle(nestingToggleLabel(m.nestingEnabled))
 		case "j", "down":
 			// Move selection down using cached values
 			if m.selectedLine < m.maxLine {
 				m.selectedLine++
 				// Update viewport with highlighted line
-				content := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+				content := m.treeContent()
 				m.viewport.SetContent(content)
 				// Auto-scroll if needed
-				if m.selectedLine >= m.viewport.YOffset+m.viewport.Height-1 {
-					m.viewport.LineDown(1)
+				if _, bottom := m.selectionRows(); bottom >= m.viewport.YOffset+m.viewport.Height-1 {
+					m.viewport.LineDown(bottom - (m.viewport.YOffset + m.viewport.Height - 1) + 1)
 				}
 			}
 			return m, nil
@@ -544,11 +2864,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.selectedLine > 0 {
 				m.selectedLine--
 				// Update viewport with highlighted line
-				content := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+				content := m.treeContent()
 				m.viewport.SetContent(content)
 				// Auto-scroll if needed
-				if m.selectedLine < m.viewport.YOffset {
-					m.viewport.LineUp(1)
+				if top, _ := m.selectionRows(); top < m.viewport.YOffset {
+					m.viewport.LineUp(m.viewport.YOffset - top)
 				}
 			}
 			return m, nil
@@ -568,8 +2888,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 
 					// Rebuild tree with new expansion
-					m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-					m.updateTreeCache()
+					m.rebuildTree()
 
 					// Try to maintain selection
 					newSelectedLine := m.selectedLine
@@ -601,53 +2920,199 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedLine = newSelectedLine
 
 					// Update viewport
-					newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+					newContent := m.treeContent()
 					m.viewport.SetContent(newContent)
 					m.lastContent = newContent
 				}
 			}
 			return m, nil
-		case "u":
-			// Toggle hidden/unhidden files and folders
-			m.showHidden = !m.showHidden
-
-			// Remember the currently selected file if one exists
-			var currentFile string
-			if f, ok := m.fileMap[m.selectedLine]; ok {
-				currentFile = f
+		case "-":
+			// Jump to the parent directory of the current selection
+			relPath := m.selectedPath()
+			if ghostPath, ok := m.ghostMap[m.selectedLine]; ok {
+				relPath = ghostPath
+			}
+			if relPath == "" {
+				return m, nil
 			}
 
-			// Rebuild tree with new hidden setting
-			m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-			m.updateTreeCache()
-
-			// Try to find the same file in the new map
-			newSelectedLine := 0
-			if currentFile != "" {
-				for line, file := range m.fileMap {
-					if file == currentFile {
-						newSelectedLine = line
-						break
-					}
+			parent := filepath.Dir(relPath)
+			if parent == "." {
+				// Top-level entries belong to the root line
+				m.selectedLine = 0
+			} else if !m.selectPath(parent) {
+				return m, nil
+			}
+			m.refreshViewport()
+			m.scrollToSelection()
+			return m, nil
+		case ">":
+			// Re-root the tree at the selected directory
+			if dirPath, ok := m.dirMap[m.selectedLine]; ok {
+				m.pushRoot(dirPath)
+			}
+			return m, nil
+		case "<":
+			// Return to the previous root, or move up to the parent directory
+			if len(m.rootStack) > 0 {
+				m.popRoot()
+				return m, nil
+			}
+			parent := internal.GetParentDirectory(m.rootPath)
+			if parent == m.rootPath {
+				return m, m.setStatus("Already at the top", true)
+			}
+			previous := filepath.Base(m.rootPath)
+			wasWatching := m.watcher != nil
+			if err := m.changeRoot(parent); err != nil {
+				return m, m.setStatus(err.Error(), true)
+			}
+			m.selectPath(previous)
+			m.refreshViewport()
+			m.scrollToSelection()
+			return m, tea.Batch(m.setStatus("Root: "+shortenPath(m.rootPath), false), m.restartWatch(wasWatching))
+		case ":":
+			// Type a directory to use as the root
+			m.changingRoot = true
+			m.changeRootErr = ""
+			m.textInput = textinput.New()
+			m.textInput.Placeholder = "~/path or /absolute/path"
+			m.textInput.Focus()
+			m.textInput.CharLimit = 1024
+			m.textInput.Width = 50
+			return m, nil
+		case "w":
+			// Toggle breadcrumb bar
+			m.showBreadcrumb = !m.showBreadcrumb
+			m.resizeViewport()
+			return m, nil
+		case "P":
+			// Toggle full vs shortened path in the header (remembered for the session)
+			m.fullHeaderPath = !m.fullHeaderPath
+			go internal.SetSessionValue("header-full-path", m.sessionID, strconv.FormatBool(m.fullHeaderPath))
+			return m, nil
+		case "F":
+			// Toggle folder-only view
+			m.dirsOnly = !m.dirsOnly
+			m.saveViewSettings()
+
+			currentSelection := m.selectedPath()
+			m.rebuildTree()
+			if currentSelection == "" || !m.selectPath(currentSelection) {
+				// A hidden file's directory is the closest visible entry
+				if currentSelection == "" || !m.selectPath(filepath.Dir(currentSelection)) {
+					m.clampSelection()
 				}
 			}
+			m.resizeViewport()
+			m.refreshViewport()
+			m.scrollToSelection()
+			return m, nil
+		case "=":
+			// Toggle directories before files
+			m.dirsFirst = !m.dirsFirst
+			m.saveViewSettings()
+
+			currentSelection := m.selectedPath()
+			m.rebuildTree()
+			if currentSelection == "" || !m.selectPath(currentSelection) {
+				m.clampSelection()
+			}
+			m.refreshViewport()
+			m.scrollToSelection()
+			return m, nil
+		case "s", "S":
+			// Cycle sort mode, or reverse the current one
+			if msg.String() == "S" {
+				m.sortReverse = !m.sortReverse
+			} else {
+				m.sortMode = (m.sortMode + 1) % sortMode(len(sortModeNames))
+			}
+			m.saveViewSettings()
 
-			// Ensure selected line is within bounds
-			if newSelectedLine > m.maxLine {
-				newSelectedLine = m.maxLine
+			currentSelection := m.selectedPath()
+			m.rebuildTree()
+			if currentSelection == "" || !m.selectPath(currentSelection) {
+				m.clampSelection()
 			}
-			if newSelectedLine < 0 {
-				newSelectedLine = 0
+			m.refreshViewport()
+			m.scrollToSelection()
+			return m, nil
+		case "+":
+			// Toggle the change markers; changes are still tracked for sorting and filters
+			m.hideDiffMarkers = !m.hideDiffMarkers
+			m.saveViewSettings()
+			m.rebuildTree()
+			m.refreshViewport()
+			return m, nil
+		case "L":
+			// Toggle line-count size indicator
+			m.showSizes = !m.showSizes
+
+			currentSelection := m.selectedPath()
+			m.rebuildTree()
+			if currentSelection == "" || !m.selectPath(currentSelection) {
+				m.clampSelection()
 			}
-			m.selectedLine = newSelectedLine
+			m.refreshViewport()
+			return m, nil
+		case "H":
+			// Run the GitHub setup again, even if it was declined
+			return m, m.runGitHubSetup()
+		case "N":
+			// Toggle wrapping of rows too long for the view
+			m.wrapNames = !m.wrapNames
+			m.refreshViewport()
+			m.scrollToSelection()
+			return m, nil
+		case "K":
+			// Toggle file sizes after names
+			m.showFileSizes = !m.showFileSizes
+			m.rebuildTree()
+			m.refreshViewport()
+			return m, nil
+		case "D":
+			// Toggle ghost entries for deleted tracked files
+			if !m.inGitRepo {
+				return m, m.setStatus("Not a git repository: deleted files unavailable", true)
+			}
+			m.showDeleted = !m.showDeleted
+			if !m.showDeleted {
+				m.deletedFiles = nil
+			}
+
+			currentSelection := m.selectedPath()
+			m.rebuildTree()
+			if currentSelection == "" || !m.selectPath(currentSelection) {
+				m.clampSelection()
+			}
+			m.refreshViewport()
+			return m, nil
+		case "u":
+			// Toggle hidden/unhidden files and folders
+			m.showHidden = !m.showHidden
+			clear(m.childCounts)
+			m.saveViewSettings()
 
-			// Update viewport with new selection
-			newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
-			m.viewport.SetContent(newContent)
-			m.lastContent = newContent
-			return m, nil
+			return m, m.rebuildAfterToggle(hiddenToggleLabel(m.showHidden))
 		case "right", "l":
 			// Vim-style expand directory (l) or arrow key (→)
+			if dirPath, ok := m.dirMap[m.selectedLine]; ok && m.skipSymlinkDirs {
+				// Symlinked directories are shown as leaves while links aren't followed
+				if info, err := os.Lstat(filepath.Join(m.rootPath, dirPath)); err == nil && info.Mode()&os.ModeSymlink != 0 {
+					return m, m.setStatus("Symlinked directory not followed (follow_symlinks = false or --no-follow-symlinks)", false)
+				}
+			}
+			if dirPath, ok := m.dirMap[m.selectedLine]; ok && m.slowDirs[dirPath] {
+				// Load a directory that was skipped for being slow
+				m.slowDirs[dirPath] = false
+				m.expandedDirs[dirPath] = true
+				m.rebuildTree()
+				m.selectPath(dirPath)
+				m.refreshViewport()
+				m.scrollToSelection()
+				return m, nil
+			}
 			if !m.nestingEnabled {
 				if dirPath, ok := m.dirMap[m.selectedLine]; ok {
 					// Mark directory as expanded
@@ -662,8 +3127,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 
 					// Rebuild tree with new expansion
-					m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-					m.updateTreeCache()
+					m.rebuildTree()
 
 					// Try to maintain selection
 					newSelectedLine := m.selectedLine
@@ -695,7 +3159,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedLine = newSelectedLine
 
 					// Update viewport
-					newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+					newContent := m.treeContent()
 					m.viewport.SetContent(newContent)
 					m.lastContent = newContent
 				}
@@ -717,8 +3181,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 
 					// Rebuild tree with new expansion
-					m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-					m.updateTreeCache()
+					m.rebuildTree()
 
 					// Try to maintain selection
 					newSelectedLine := m.selectedLine
@@ -750,23 +3213,35 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.selectedLine = newSelectedLine
 
 					// Update viewport
-					newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+					newContent := m.treeContent()
 					m.viewport.SetContent(newContent)
 					m.lastContent = newContent
 				}
 			}
 			return m, nil
 		case "enter", " ":
+			// Restore a deleted file when its ghost entry is selected
+			if ghostPath, ok := m.ghostMap[m.selectedLine]; ok {
+				if m.dryRun {
+					return m, m.setStatus(fmt.Sprintf("[dry-run] Would restore %s", ghostPath), false)
+				}
+				if err := internal.RestoreDeletedFile(m.rootPath, ghostPath); err != nil {
+					return m, m.setStatus(err.Error(), true)
+				}
+				m.rebuildTree()
+				m.selectPath(ghostPath)
+				m.clampSelection()
+				m.refreshViewport()
+				return m, m.setStatus(fmt.Sprintf("Restored %s", ghostPath), false)
+			}
+
 			// Get the file at the selected line (only files are in the map, not directories)
 			if filePath, ok := m.fileMap[m.selectedLine]; ok {
 				fullPath := filepath.Join(m.rootPath, filePath)
 
 				// Make sure it's actually a file, not a directory
 				if info, err := os.Stat(fullPath); err == nil && !info.IsDir() {
-					// Write to Skate for viewer to pick up, silently ignore errors
-					key := fmt.Sprintf("vinw-current-file@%s", m.sessionID)
-					cmd := exec.Command("skate", "set", key, fullPath)
-					cmd.Run() // Ignore errors silently
+					m.sendToViewer(fullPath)
 				}
 			}
 			// If it's a directory or not in map, do nothing (directories aren't selectable)
@@ -780,6 +3255,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textInput.CharLimit = 255
 			m.textInput.Width = 50
 			return m, nil
+		case "o":
+			// Create new file and open it in the viewer
+			m.creatingMode = creationFileAndOpen
+			m.textInput = textinput.New()
+			m.textInput.Placeholder = "filename.ext"
+			m.textInput.Focus()
+			m.textInput.CharLimit = 255
+			m.textInput.Width = 50
+			return m, nil
+		case "m":
+			// Rename the selected file or directory
+			relPath := m.selectedPath()
+			if relPath == "" || m.ghostMap[m.selectedLine] != "" {
+				return m, nil
+			}
+			m.renaming = relPath
+			m.renameErr = ""
+			m.textInput = textinput.New()
+			m.textInput.SetValue(filepath.Base(relPath))
+			m.textInput.Focus()
+			m.textInput.CharLimit = 255
+			m.textInput.Width = 50
+			return m, nil
 		case "A":
 			// Create new directory
 			m.creatingMode = creationDirectory
@@ -825,51 +3323,116 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+	case treeRebuildMsg:
+		m.rebuildKeepingFile()
+		return m, m.setStatus(msg.label, false)
+
+	case githubSetupMsg:
+		// The setup may have created the repo or its remote
+		root := m.watchRoot()
+		m.inGitRepo = internal.IsGitRepo(root)
+		m.repoRoot, m.gitDir = "", ""
+		if m.inGitRepo {
+			m.repoRoot = internal.GetRepoRoot(root)
+			m.gitDir = internal.GetGitDir(root)
+		}
+		m.backgroundRefresh(true)
+		if msg.err != nil {
+			reason := msg.output
+			if reason == "" {
+				reason = msg.err.Error()
+			}
+			return m, m.setStatus("GitHub setup: "+reason, true)
+		}
+		return m, m.setStatus("GitHub setup finished", false)
+
+	case editorFinishedMsg:
+		internal.LogError("editor exited with error", msg.err)
+		// Editor closed - refresh diff markers for any edits
+		m.refreshDiffs()
+		m.rebuildTree()
+		m.clampSelection()
+		m.refreshViewport()
+		return m, nil
+
+	case clearStatusMsg:
+		// Only clear if no newer message replaced it
+		if msg.seq == m.statusSeq {
+			m.statusMsg = ""
+			m.statusIsError = false
+		}
+		return m, nil
+
 	case clearCopyHintMsg:
 		m.showCopyHint = false
 		m.copiedPath = ""
+		m.copiedForm = ""
 		return m, nil
 
-	case tickMsg:
-		// Update git diff cache efficiently with one call
-		m.diffCache = internal.GetAllGitDiffs()
+	case heartbeatMsg:
+		go writeHeartbeat(m.sessionID)
+		return m, heartbeat()
 
-		// Remember the currently selected file if one exists
-		var currentFile string
-		if f, ok := m.fileMap[m.selectedLine]; ok {
-			currentFile = f
+	case viewingFileMsg:
+		// Re-mark the tree only when the viewer moved to another file
+		if msg.path != m.viewingFile {
+			m.viewingFile = msg.path
+			m.rebuildTree()
+			m.refreshViewport()
 		}
-
-		// Rebuild tree with cached diff data and gitignore settings
-		m.tree, m.fileMap, m.dirMap = buildTreeWithMaps(m.rootPath, m.diffCache, m.gitignore, m.respectIgnore, m.nestingEnabled, m.expandedDirs, m.showHidden)
-		m.updateTreeCache()
-
-		// Try to maintain selection on the same file
-		if currentFile != "" {
-			for line, file := range m.fileMap {
-				if file == currentFile {
-					m.selectedLine = line
-					break
-				}
-			}
+		if path, ok := m.newEditSignal(msg.edited); ok {
+			// The viewer's editor just saved, don't wait for the next refresh
+			m.backgroundRefresh(true)
+			return m, tea.Batch(m.pollViewing(), m.setStatus("Edited in viewer: "+m.editedLabel(path), false))
 		}
+		return m, m.pollViewing()
 
-		// Ensure selected line is within bounds
-		if m.selectedLine > m.maxLine {
-			m.selectedLine = m.maxLine
+	case largeFilesMsg:
+		if msg.err != nil {
+			return m, m.setStatus(msg.err.Error(), true)
 		}
-		if m.selectedLine < 0 {
-			m.selectedLine = 0
+		m.largeFiles = msg.files
+		m.largeCursor = 0
+		m.showLargeFiles = true
+		m.statusMsg = ""
+		return m, nil
+
+	case commitDoneMsg:
+		return m, m.finishCommit(msg)
+
+	case dirStatMsg:
+		// Rebuild only when a watched directory's entries changed
+		changed := m.dirMtimes != nil && internal.DirsChanged(m.dirMtimes, msg.mtimes)
+		m.dirMtimes = msg.mtimes
+		if changed {
+			return m, tea.Batch(func() tea.Msg { return fsChangedMsg{} }, m.statWatch())
 		}
+		return m, m.statWatch()
 
-		// Only update viewport if content has changed
-		newContent := renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
-		if newContent != m.lastContent {
-			m.viewport.SetContent(newContent)
-			m.lastContent = newContent
+	case fsEventMsg:
+		// Debounce so a burst of writes causes a single rebuild
+		if m.fsPending {
+			return m, m.waitForFSEvent()
 		}
+		m.fsPending = true
+		return m, tea.Batch(m.waitForFSEvent(), tea.Tick(fsDebounce, func(time.Time) tea.Msg {
+			return fsChangedMsg{}
+		}))
+
+	case fsChangedMsg:
+		// Added or removed entries may be untracked files, which the fingerprint misses
+		m.fsPending = false
+		m.backgroundRefresh(true)
+		return m, nil
 
-		return m, tick()
+	case tickMsg:
+		if msg.gen != m.refreshGen {
+			return m, nil
+		}
+		// Without fsnotify nothing reports edits to clean files, which the
+		// fingerprint can't see, so every tick runs the full diff
+		m.backgroundRefresh(m.watcher == nil)
+		return m, tick(m.refreshInterval, m.refreshGen)
 	}
 
 	// Update viewport (handles scrolling)
@@ -894,10 +3457,10 @@ Session ID: %s
 
 To open the viewer, run in another terminal:
 
-  vinw-viewer %s
+  %s
 
-Press 'c' to copy command to clipboard
-Press any other key to continue...`, m.sessionID, m.sessionID)
+%sPress 'c' to copy command to clipboard
+%sPress any other key to continue...`, m.sessionID, m.viewerCommand(false), m.missingToolsNotice(), m.fullViewerCommandHint())
 
 		startupStyle := lipgloss.NewStyle().
 			Padding(2, 4).
@@ -921,104 +3484,409 @@ Press any other key to continue...`, m.sessionID, m.sessionID)
 
 Run this command in another terminal:
 
-  vinw-viewer %s
+  %s
+
+Session ID: %s
+
+Press 'c' to copy command to clipboard
+%sPress any other key to dismiss...`, m.viewerCommand(false), m.sessionID, m.fullViewerCommandHint())
+
+		viewerStyle := lipgloss.NewStyle().
+			Padding(2, 4).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("42"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			viewerStyle.Render(viewerText),
+		)
+	}
+
+	// Show change-root prompt
+	if m.changingRoot {
+		hints := "enter: change root • esc: cancel"
+		if m.changeRootErr != "" {
+			errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+			hints = errStyle.Render(m.changeRootErr) + "\n\n" + hints
+		}
+
+		promptText := fmt.Sprintf(`Change Root
+
+Current: %s
+
+%s
+
+%s`, shortenPath(m.rootPath), m.textInput.View(), hints)
+
+		promptStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("170"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			promptStyle.Render(promptText),
+		)
+	}
+
+	// Show rename prompt
+	if m.renaming != "" {
+		hints := "enter: confirm • esc: cancel"
+		if m.renameErr != "" {
+			errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("208"))
+			hints = errStyle.Render(m.renameErr) + "\n\n" + hints
+		}
+
+		promptText := fmt.Sprintf(`Rename
+
+Renaming: %s
+
+%s
+
+%s`, m.renaming, m.textInput.View(), hints)
+
+		promptStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("170"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			promptStyle.Render(promptText),
+		)
+	}
+
+	// Show creation prompt
+	if m.creatingMode != creationNone {
+		title := "Create New File"
+		if m.creatingMode == creationDirectory {
+			title = "Create New Directory"
+		} else if m.creatingMode == creationFileAndOpen {
+			title = "Create and Open New File"
+		}
+
+		// Shorten path for display
+		targetPath := m.creationTargetDir()
+		displayPath := targetPath
+		if home := os.Getenv("HOME"); home != "" && strings.HasPrefix(targetPath, home) {
+			displayPath = "~" + strings.TrimPrefix(targetPath, home)
+		}
+
+		hints := "enter: confirm • esc: cancel"
+		if m.nameSuggestion != "" {
+			hints = fmt.Sprintf("tab: use %s • enter: confirm • esc: cancel", m.nameSuggestion)
+		}
+
+		// Live verdict on the typed name
+		if name := strings.TrimSpace(m.textInput.Value()); name != "" {
+			verdict := lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Render("✓ " + name + " can be created")
+			if m.createProblem != "" {
+				verdict = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("✗ " + m.createProblem)
+			}
+			hints = verdict + "\n\n" + hints
+		}
+
+		promptText := fmt.Sprintf(`%s
+
+Location: %s
+
+%s
+
+%s`, title, displayPath, m.textInput.View(), hints)
+
+		promptStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("170"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			promptStyle.Render(promptText),
+		)
+	}
+
+	// Show quit confirmation
+	if m.quitPending {
+		confirmText := fmt.Sprintf(`You have %d changed file(s) — quit anyway?
+
+y: quit • any other key: stay`, len(m.diffCache))
+
+		confirmStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("214"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			confirmStyle.Render(confirmText),
+		)
+	}
+
+	// Show deletion confirmation
+	if m.deletePending != nil {
+		itemName := filepath.Base(m.deletePending.path)
+		itemType := "file"
+		warning := ""
+
+		if m.deletePending.isDir {
+			itemType = "directory"
+			if m.deletePending.itemCount > 0 {
+				warning = fmt.Sprintf("\n⚠  WARNING: This directory contains %d item(s)", m.deletePending.itemCount)
+			} else {
+				warning = "\n(empty directory)"
+			}
+		}
+
+		confirmText := fmt.Sprintf(`⚠  Delete %s?
+
+%s%s
+
+Moved to trash, ctrl+z or U to restore
+(deleted permanently if the trash is unavailable).
+
+y: confirm deletion • n/esc: cancel`, itemType, itemName, warning)
+
+		confirmStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("196")) // Red for danger
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			confirmStyle.Render(confirmText),
+		)
+	}
+
+	// Show named registers popup
+	if m.showRegisters {
+		s := strings.Builder{}
+		s.WriteString("Registers\n\n")
+
+		names := m.registerNames()
+		if len(names) == 0 {
+			s.WriteString("No registers yet. Use \"ay to yank a path into register a.\n")
+		}
+		for i, reg := range names {
+			if i == m.registerCursor {
+				s.WriteString("> ")
+			} else {
+				s.WriteString("  ")
+			}
+			s.WriteString(fmt.Sprintf("\"%c  %s\n", reg, shortenPath(m.registers[reg])))
+		}
+
+		s.WriteString("\nj/k: navigate • enter: copy • x: clear • esc: close")
+
+		registerStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			registerStyle.Render(s.String()),
+		)
+	}
+
+	// Show bookmarks popup
+	if m.showBookmarks {
+		s := strings.Builder{}
+		s.WriteString("Bookmarks\n\n")
+
+		if len(m.bookmarks) == 0 {
+			s.WriteString("No bookmarks yet. Select a directory and press b.\n")
+		}
+		for i, bookmark := range m.bookmarks {
+			if i == m.bookmarkCursor {
+				s.WriteString("> ")
+			} else {
+				s.WriteString("  ")
+			}
+			s.WriteString(bookmark + "\n")
+		}
+
+		s.WriteString("\nj/k: navigate • enter: jump • x: remove • esc: close")
+
+		bookmarkStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			bookmarkStyle.Render(s.String()),
+		)
+	}
+
+	// Show copy/move-to popup
+	if m.showFileTo {
+		s := strings.Builder{}
+		s.WriteString(fmt.Sprintf("Copy or move %s to\n\n", filepath.Base(m.fileToSource)))
+
+		targets := m.fileToTargets()
+		if len(targets) == 0 {
+			s.WriteString("No bookmarked directories. Select a directory and use b (or \"ay) to save one.\n")
+		}
+		for i, target := range targets {
+			if i == m.fileToCursor {
+				s.WriteString("> ")
+			} else {
+				s.WriteString("  ")
+			}
+			s.WriteString(fmt.Sprintf("%s  %s/\n", target.label, shortenPath(target.path)))
+		}
+
+		s.WriteString("\nj/k: navigate • enter: copy • M: move • esc: close")
+
+		fileToStyle := lipgloss.NewStyle().
+			Padding(1, 2).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			fileToStyle.Render(s.String()),
+		)
+	}
+
+	// Show large files popup
+	if m.showLargeFiles {
+		s := strings.Builder{}
+		s.WriteString(fmt.Sprintf("Tracked files over %s\n\n", internal.FormatSize(m.largeFileThreshold)))
 
-Session ID: %s
+		if len(m.largeFiles) == 0 {
+			s.WriteString("None found.\n")
+		}
+		const maxLarge = 20
+		binaryStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		for i, file := range m.largeFiles {
+			if i == maxLarge {
+				s.WriteString(fmt.Sprintf("  ... %d more\n", len(m.largeFiles)-maxLarge))
+				break
+			}
+			if i == m.largeCursor {
+				s.WriteString("> ")
+			} else {
+				s.WriteString("  ")
+			}
+			s.WriteString(fmt.Sprintf("%9s  %s", internal.FormatSize(file.Size), file.Path))
+			if file.Binary {
+				s.WriteString(binaryStyle.Render("  binary"))
+			}
+			s.WriteString("\n")
+		}
 
-Press 'c' to copy command to clipboard
-Press any other key to dismiss...`, m.sessionID, m.sessionID)
+		s.WriteString("\nj/k: navigate • enter: add to .gitignore • esc: close")
 
-		viewerStyle := lipgloss.NewStyle().
-			Padding(2, 4).
+		largeStyle := lipgloss.NewStyle().
+			Padding(1, 2).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("42"))
+			BorderForeground(lipgloss.Color("62"))
 
 		return lipgloss.Place(
 			m.width,
 			m.height,
 			lipgloss.Center,
 			lipgloss.Center,
-			viewerStyle.Render(viewerText),
+			largeStyle.Render(s.String()),
 		)
 	}
 
-	// Show creation prompt
-	if m.creatingMode != creationNone {
-		title := "Create New File"
-		if m.creatingMode == creationDirectory {
-			title = "Create New Directory"
-		}
+	// Show trash popup
+	if m.showTrash {
+		s := strings.Builder{}
+		s.WriteString("Trash\n\n")
 
-		// Determine target location for display
-		targetPath := m.rootPath
-		if dirPath, ok := m.dirMap[m.selectedLine]; ok {
-			targetPath = filepath.Join(m.rootPath, dirPath)
-		} else if filePath, ok := m.fileMap[m.selectedLine]; ok {
-			targetPath = filepath.Join(m.rootPath, filepath.Dir(filePath))
+		if len(m.trashed) == 0 {
+			s.WriteString("Nothing deleted this session.\n")
 		}
-
-		// Shorten path for display
-		displayPath := targetPath
-		if home := os.Getenv("HOME"); home != "" && strings.HasPrefix(targetPath, home) {
-			displayPath = "~" + strings.TrimPrefix(targetPath, home)
+		for i, item := range m.trashed {
+			if i == m.trashCursor {
+				s.WriteString("> ")
+			} else {
+				s.WriteString("  ")
+			}
+			name := shortenPath(item.OriginalPath)
+			if item.IsDir {
+				name += "/"
+			}
+			s.WriteString(fmt.Sprintf("%s  %s\n", item.DeletedAt.Format("15:04:05"), name))
 		}
 
-		promptText := fmt.Sprintf(`%s
-
-Location: %s
-
-%s
-
-enter: confirm • esc: cancel`, title, displayPath, m.textInput.View())
+		s.WriteString("\nj/k: navigate • enter: restore • esc: close")
 
-		promptStyle := lipgloss.NewStyle().
+		trashStyle := lipgloss.NewStyle().
 			Padding(1, 2).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("170"))
+			BorderForeground(lipgloss.Color("62"))
 
 		return lipgloss.Place(
 			m.width,
 			m.height,
 			lipgloss.Center,
 			lipgloss.Center,
-			promptStyle.Render(promptText),
+			trashStyle.Render(s.String()),
 		)
 	}
 
-	// Show deletion confirmation
-	if m.deletePending != nil {
-		itemName := filepath.Base(m.deletePending.path)
-		itemType := "file"
-		warning := ""
+	if m.showTypes {
+		s := strings.Builder{}
+		s.WriteString("File Types\n\n")
 
-		if m.deletePending.isDir {
-			itemType = "directory"
-			if m.deletePending.itemCount > 0 {
-				warning = fmt.Sprintf("\n⚠  WARNING: This directory contains %d item(s)", m.deletePending.itemCount)
-			} else {
-				warning = "\n(empty directory)"
+		summary := m.typeSummary()
+		if len(summary) == 0 {
+			s.WriteString("No files visible.\n")
+		}
+		const maxTypes = 20
+		for i, line := range summary {
+			if i == maxTypes {
+				s.WriteString(fmt.Sprintf("  ... %d more\n", len(summary)-maxTypes))
+				break
 			}
+			s.WriteString(line + "\n")
 		}
 
-		confirmText := fmt.Sprintf(`⚠  Delete %s?
-
-%s%s
-
-This action cannot be undone!
-
-y: confirm deletion • n/esc: cancel`, itemType, itemName, warning)
+		s.WriteString("\nCounts cover visible files (filters, ignores and collapsed folders apply)\n")
+		s.WriteString("any key: close")
 
-		confirmStyle := lipgloss.NewStyle().
+		typeStyle := lipgloss.NewStyle().
 			Padding(1, 2).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("196")) // Red for danger
+			BorderForeground(lipgloss.Color("62"))
 
 		return lipgloss.Place(
 			m.width,
 			m.height,
 			lipgloss.Center,
 			lipgloss.Center,
-			confirmStyle.Render(confirmText),
+			typeStyle.Render(s.String()),
 		)
 	}
 
@@ -1036,24 +3904,68 @@ Navigation (Vim-style)
 ──────────────────────
   j, ↓          Move down
   k, ↑          Move up
+  gg, G         Jump to top / bottom
+  gh            Open the repo's remote in the browser
+  gb            Open the selected file on the remote (current branch)
+  ctrl+d/u      Move half a page down / up
   h, ←          Collapse directory
   l, →          Expand directory
+  -             Jump to parent directory
+  >             Use selected directory as root
+  <             Return to previous root, or move up to the parent
+  :             Type a directory to use as root
   Space/Enter   Select file to view
   u             Toggle hidden files
   i             Toggle gitignore
   n             Toggle full nesting
+  z1-z9         Show the tree exactly N levels deep
+  Z             Zoom to the selection's branch (again to restore)
+  E / X         Expand / collapse all directories
+  f             Toggle focus mode (hide header and footer)
+  D             Toggle deleted files (Enter restores)
+  L             Toggle file size indicator
+  K             Toggle file sizes (bytes) after names
+  N             Toggle wrapping of long names
+  +             Toggle change markers ((+N)/(new))
+  s             Cycle sort mode (name/changes/size/modified/extension)
+  S             Reverse sort order
+  =             Toggle directories before files
+  F             Toggle folders-only view
+  I             Show file type summary
+  P             Toggle full path in header
+  w             Toggle breadcrumb bar
   r             Refresh git status (fast)
   R             Full refresh (slow)
   a             Create new file
   A             Create new directory
-  d             Delete file/directory
-  c             Copy path to clipboard
+  o             Create new file and open it
+  d             Delete file/directory (to trash)
+  m             Rename file/directory
+  U             Show trash, restore deleted items
+  ctrl+z        Undo the last delete
+  M             Copy/move selected file to a bookmark or register
+  W             Stage everything and commit (prompts for a message)
+  O             List large tracked files, add one to .gitignore
+  H             Run GitHub setup (new repo or missing remote)
+  c             Copy absolute path to clipboard
+  C             Copy relative path to clipboard
+  Y             Copy repo-relative (git) path to clipboard
+  V             Copy session ID to clipboard
+  ctrl+r        Reload global and project config
+  /             Search the tree (enter jumps to next match)
+  B             Copy location breadcrumb
+  "ay           Yank path into register a
+  "ap           Copy register a to clipboard
+  @             Show registers
+  b             Bookmark selected directory (again to remove)
+  '             Show bookmarks (enter jumps)
   v             Show viewer command
   ?             Toggle this help
   q             Quit
 
 Git Features
 ────────────
+  • Inactive outside a git repository
   • Shows uncommitted changes (+N)
   • Works without remote repos
   • Auto-creates GitHub repos
@@ -1066,77 +3978,810 @@ Symlinks
   • Broken symlinks shown in red
   • Loop detection prevents hangs
 
-Press any key to dismiss...`
+Press any key to dismiss...`
+
+		helpStyle := lipgloss.NewStyle().
+			Padding(2, 4).
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("62"))
+
+		return lipgloss.Place(
+			m.width,
+			m.height,
+			lipgloss.Center,
+			lipgloss.Center,
+			helpStyle.Render(helpText),
+		)
+	}
+
+	if m.focusMode {
+		return fmt.Sprintf("%s\n%s", m.viewport.View(), m.focusIndicator())
+	}
+	return fmt.Sprintf("%s\n%s\n%s", m.headerView(), m.viewport.View(), m.footerView())
+}
+
+// viewerCommand returns the command that starts the paired viewer, optionally
+// with the absolute path of the binary for terminals with a different PATH
+func (m model) viewerCommand(fullPath bool) string {
+	name := m.viewerName
+	if fullPath && m.viewerBinary != "" {
+		// Keep any arguments of a configured wrapper, only the program gets resolved
+		_, args, _ := strings.Cut(name, " ")
+		name = strings.TrimSpace(m.viewerBinary + " " + args)
+	}
+	return fmt.Sprintf("%s %s", name, m.sessionID)
+}
+
+// viewerProgram returns the program part of the viewer command, for the copy hint
+func (m model) viewerProgram() string {
+	program, _, _ := strings.Cut(m.viewerName, " ")
+	return filepath.Base(program)
+}
+
+// viewerNameSetting resolves the command that starts the viewer from VINW_VIEWER,
+// then the config file, then the default. It may include arguments for a wrapper script.
+func viewerNameSetting(config *internal.Config) string {
+	if name := strings.TrimSpace(os.Getenv("VINW_VIEWER")); name != "" {
+		return name
+	}
+	if name := strings.TrimSpace(config.String("viewer_command", "")); name != "" {
+		return name
+	}
+	return "vinw-viewer"
+}
+
+// fullViewerCommandHint offers the full-path copy in the viewer popups when the binary was found
+func (m model) fullViewerCommandHint() string {
+	if m.viewerBinary == "" {
+		return ""
+	}
+	return fmt.Sprintf("Press 'C' to copy it with the full path (%s)\n", shortenPath(m.viewerBinary))
+}
+
+// resolveViewerBinary finds the viewer program named by the viewer command next
+// to this executable or on PATH. Paths are only made absolute.
+func resolveViewerBinary(viewerName string) string {
+	program, _, _ := strings.Cut(viewerName, " ")
+	if program == "" {
+		return ""
+	}
+	if strings.ContainsRune(program, filepath.Separator) || strings.HasPrefix(program, "~") {
+		program = expandHome(program)
+		if info, err := os.Stat(program); err == nil && !info.IsDir() {
+			if abs, err := filepath.Abs(program); err == nil {
+				return abs
+			}
+		}
+		return ""
+	}
+	if exe, err := os.Executable(); err == nil {
+		sibling := filepath.Join(filepath.Dir(exe), program)
+		if info, err := os.Stat(sibling); err == nil && !info.IsDir() {
+			return sibling
+		}
+	}
+	if path, err := exec.LookPath(program); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			return abs
+		}
+	}
+	return ""
+}
+
+// missingToolsNotice lists unavailable optional integrations for the startup popup
+func (m model) missingToolsNotice() string {
+	if len(m.missingTools) == 0 {
+		return ""
+	}
+	warnStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	var notice strings.Builder
+	notice.WriteString(warnStyle.Render("Unavailable integrations:") + "\n")
+	for _, tool := range m.missingTools {
+		notice.WriteString(fmt.Sprintf("  %s not found: %s\n", tool.Binary, tool.Impact))
+	}
+	notice.WriteString("\n")
+	return notice.String()
+}
+
+func shortenPath(path string) string {
+	home := os.Getenv("HOME")
+	if home != "" && strings.HasPrefix(path, home) {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+	return path
+}
+
+func (m model) headerView() string {
+	displayPath := shortenPath(m.rootPath)
+	if m.fullHeaderPath {
+		displayPath = m.rootPath
+	}
+	title := fmt.Sprintf("ⓥⓘⓝⓦ - %s", displayPath)
+	if len(m.roots) > 0 && m.relativeRoots() != nil {
+		title = fmt.Sprintf("ⓥⓘⓝⓦ - %d roots in %s", len(m.roots), displayPath)
+	}
+
+	// Diffs and git operations are relative to the repo, show it when watching a subtree
+	if m.repoRoot != "" && m.repoRoot != m.rootPath {
+		repoPath := shortenPath(m.repoRoot)
+		if m.fullHeaderPath {
+			repoPath = m.repoRoot
+		}
+		title += fmt.Sprintf(" (repo: %s)", repoPath)
+	}
+	if m.dirsOnly {
+		title += " [folders only]"
+	}
+	if m.dryRun {
+		title += " [DRY RUN]"
+	}
+
+	// Add copy hint if active
+	if m.showCopyHint {
+		copyHintStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("42")). // Green
+			Bold(true)
+		label := "Copied"
+		if m.copiedForm != "" {
+			label = fmt.Sprintf("Copied %s", m.copiedForm)
+		}
+		hint := copyHintStyle.Render(fmt.Sprintf(" [%s: %s]", label, m.copiedPath))
+		title = title + hint
+	}
+
+	// Use theme colors for header
+	themedHeaderStyle := m.theme.CreateHeaderStyle()
+	header := themedHeaderStyle.Width(m.width).Render(title)
+
+	// Optional breadcrumb bar showing the selection's ancestry
+	if m.showBreadcrumb {
+		parts := m.breadcrumbParts()
+		// Leave room for the footer-style horizontal padding
+		breadcrumb := fitBreadcrumb(parts, m.width-2)
+		header += "\n" + footerStyle.Width(m.width).Render(breadcrumb)
+	}
+	return header
+}
+
+// resizeViewport recomputes the viewport height after the header or footer changes size
+func (m *model) resizeViewport() {
+	if !m.ready {
+		return
+	}
+	headerHeight, footerHeight := m.chromeHeights()
+	m.viewport.Height = m.height - headerHeight - footerHeight
+	m.viewport.YPosition = headerHeight
+}
+
+// chromeHeights returns the lines taken by the header and footer, which focus
+// mode shrinks to a single indicator line
+func (m model) chromeHeights() (int, int) {
+	if m.focusMode {
+		return 0, 1
+	}
+	return lipgloss.Height(m.headerView()), lipgloss.Height(m.footerView())
+}
+
+// focusIndicator is the only chrome shown in focus mode
+func (m model) focusIndicator() string {
+	if m.statusMsg != "" {
+		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+		if m.statusIsError {
+			statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		}
+		return statusStyle.Render(m.statusMsg)
+	}
+	indicatorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	return indicatorStyle.Render("focus mode • f: exit")
+}
+
+// activeFilters describes the filters currently hiding entries from the tree
+func (m model) activeFilters() []string {
+	var filters []string
+	if m.dirsOnly {
+		filters = append(filters, "folders only")
+	}
+	for _, source := range []string{".vinwignore", "always_skip"} {
+		if m.excluded[source] > 0 {
+			filters = append(filters, source)
+		}
+	}
+	return filters
+}
+
+// hiddenEntries counts the entries the active filters hid in the directories
+// that were read. Collapsed directories aren't read, so they don't add to it.
+func (m model) hiddenEntries() int {
+	hidden := m.filteredFiles
+	for _, count := range m.excluded {
+		hidden += count
+	}
+	return hidden
+}
+
+// truncationNotices describes the parts of the tree left out by the builder's
+// limits and how to get them back
+func (m model) truncationNotices() []string {
+	var notices []string
+	if m.slowSkipped > 0 {
+		notices = append(notices, fmt.Sprintf("%d slow dir(s) not loaded (l to load)", m.slowSkipped))
+	}
+	if m.depthLimited > 0 {
+		notices = append(notices, fmt.Sprintf("%d dir(s) past max depth %d (> to re-root)", m.depthLimited, maxDepth))
+	}
+	return notices
+}
+
+func (m model) footerView() string {
+	ignoreStatus := "OFF"
+	if m.respectIgnore {
+		ignoreStatus = "ON"
+	}
+	hiddenStatus := "OFF"
+	if m.showHidden {
+		hiddenStatus = "ON"
+	}
+	nestStatus := "OFF"
+	if m.nestingEnabled {
+		nestStatus = "ON"
+	}
+	sizeStatus := "OFF"
+	if m.showSizes {
+		sizeStatus = "ON"
+	}
+	wrapStatus := "OFF"
+	if m.wrapNames {
+		wrapStatus = "ON"
+	}
+	fileSizeStatus := "OFF"
+	if m.showFileSizes {
+		fileSizeStatus = "ON"
+	}
+	deletedStatus := "OFF"
+	if m.showDeleted {
+		deletedStatus = "ON"
+	}
+	dirsFirstStatus := "OFF"
+	if m.dirsFirst {
+		dirsFirstStatus = "ON"
+	}
+	markerStatus := "ON"
+	if m.hideDiffMarkers {
+		markerStatus = "OFF"
+	}
+	// Three lines for skinny layout
+	line1 := fmt.Sprintf("j/k: nav | h/l: collapse/expand | u: hidden [%s] | L: sizes [%s] | K: bytes [%s] | N: wrap [%s] | s/S: sort [%s] | =: dirs first [%s] | r/R: refresh", hiddenStatus, sizeStatus, fileSizeStatus, wrapStatus, m.sortLabel(), dirsFirstStatus)
+	line2 := fmt.Sprintf("%s%s | i: git [%s] | n: nesting [%s] | D: deleted [%s] | +: markers [%s] | t/T: theme [%s]", m.gitState(), m.selectionModified(), ignoreStatus, nestStatus, deletedStatus, markerStatus, m.theme.Current.Name)
+	if !m.inGitRepo {
+		// Git toggles do nothing outside a repository
+		line2 = fmt.Sprintf("%s%s | i: gitignore [%s] | n: nesting [%s] | t/T: theme [%s]", m.gitState(), m.selectionModified(), ignoreStatus, nestStatus, m.theme.Current.Name)
+	}
+	line3 := "a: new file | A: new dir | m: rename | d: delete | c/C: copy abs/rel path | space/enter: select | ?: help | q: quit"
+
+	// The search prompt replaces the key hints while typing
+	if m.searching {
+		count := fmt.Sprintf("%d matches", len(m.searchMatches))
+		if len(m.searchMatches) == 1 {
+			count = "1 match"
+		}
+		countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+		line3 = m.searchInput.View() + "  " + countStyle.Render(count)
+	}
+
+	if m.committing {
+		line3 = m.commitInput.View()
+	}
+
+	// A transient status message temporarily replaces the key hints
+	if m.statusMsg != "" {
+		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+		if m.statusIsError {
+			statusStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		}
+		line3 = statusStyle.Render(m.statusMsg)
+	}
+
+	info := line1 + "\n" + line2 + "\n" + line3
+
+	// Make it obvious when a filter is hiding part of the tree
+	if filters := m.activeFilters(); len(filters) > 0 {
+		filterText := "filter: " + strings.Join(filters, ", ")
+		if hidden := m.hiddenEntries(); hidden > 0 {
+			filterText = fmt.Sprintf("%d hidden in open dirs (%s)", hidden, filterText)
+		}
+		filterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		info += "\n" + filterStyle.Render(filterText)
+	}
+
+	// Never let a truncated tree pass for a complete one
+	if notices := m.truncationNotices(); len(notices) > 0 {
+		truncStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+		info += "\n" + truncStyle.Render("tree incomplete: "+strings.Join(notices, " • "))
+	}
+	return footerStyle.Width(m.width).Render(info)
+}
+
+// defaultRefreshInterval is used when no interval is configured.
+// Manual refresh with 'r' is preferred for performance, so it's kept long
+const defaultRefreshInterval = 60 * time.Second
+
+// defaultSlowDirThreshold is how long a directory read may take before it's
+// treated as a slow mount and no longer auto-expanded
+const defaultSlowDirThreshold = 500 * time.Millisecond
+
+// backgroundRefresh re-reads git diffs and rebuilds the tree, keeping the
+// selection on the same file and screen row. Unless forced, the diffs are
+// only re-run when the repo fingerprint changed.
+func (m *model) backgroundRefresh(force bool) {
+	// Entries may have been added or removed anywhere
+	clear(m.childCounts)
+	if force {
+		m.refreshDiffs()
+	} else {
+		m.refreshDiffsIfChanged()
+	}
+
+	// Remember the currently selected file if one exists
+	var currentFile string
+	if f, ok := m.fileMap[m.selectedLine]; ok {
+		currentFile = f
+	}
+
+	// Remember which screen row the selection occupies
+	top, _ := m.selectionRows()
+	screenRow := top - m.viewport.YOffset
+
+	// Rebuild tree with cached diff data and gitignore settings
+	m.rebuildTree()
+
+	// Try to maintain selection on the same file
+	if currentFile != "" {
+		for line, file := range m.fileMap {
+			if file == currentFile {
+				m.selectedLine = line
+				break
+			}
+		}
+	}
+
+	// Ensure selected line is within bounds
+	if m.selectedLine > m.maxLine {
+		m.selectedLine = m.maxLine
+	}
+	if m.selectedLine < 0 {
+		m.selectedLine = 0
+	}
+
+	// Only update viewport if content has changed
+	newContent := m.treeContent()
+	if newContent != m.lastContent {
+		m.viewport.SetContent(newContent)
+		m.lastContent = newContent
+	}
+
+	// Keep the selection on the same screen row so background refreshes don't jump
+	m.keepSelectionRow(screenRow)
+}
+
+// defaultWatchInterval is how often watched directories are stat'd for changes
+const defaultWatchInterval = 2 * time.Second
+
+// largeTreeLines is the tree size from which toggles announce the rebuild
+// before running it, so the status line shows while the tree is rebuilt
+const largeTreeLines = 5000
+
+// rebuildAfterToggle rebuilds the tree for a changed setting, keeping the
+// selected file, and reports the new setting in the status line. Large trees
+// show the message first and rebuild on the next update.
+func (m *model) rebuildAfterToggle(label string) tea.Cmd {
+	if len(m.treeLines) >= largeTreeLines {
+		return tea.Batch(m.setStatus(label+" (rebuilding…)", false), func() tea.Msg {
+			return treeRebuildMsg{label: label}
+		})
+	}
+	m.rebuildKeepingFile()
+	return m.setStatus(label, false)
+}
+
+// rebuildKeepingFile rebuilds the tree and moves the selection to the file
+// that was selected before, or the top if it's gone
+func (m *model) rebuildKeepingFile() {
+	currentFile := m.fileMap[m.selectedLine]
+	m.rebuildTree()
+	m.selectedLine = 0
+	if currentFile != "" {
+		m.selectPath(currentFile)
+	}
+	m.clampSelection()
+	m.refreshViewport()
+	m.scrollToSelection()
+}
+
+// ignoreToggleLabel, hiddenToggleLabel and nestingToggleLabel describe the
+// toggles' new states for the status line
+func ignoreToggleLabel(respect bool) string {
+	if respect {
+		return "gitignore: ON (hiding ignored files)"
+	}
+	return "gitignore: OFF (showing ignored files)"
+}
+
+func hiddenToggleLabel(show bool) string {
+	if show {
+		return "Showing hidden files"
+	}
+	return "Hiding hidden files"
+}
+
+func nestingToggleLabel(enabled bool) string {
+	if enabled {
+		return "nesting: ON (all directories expanded)"
+	}
+	return "nesting: OFF"
+}
+
+// restartWatch resumes watching after a root change: a new watcher needs a
+// listener, and stat polling needs rescheduling if it was the active mechanism
+func (m model) restartWatch(wasWatching bool) tea.Cmd {
+	if m.watcher != nil {
+		return m.waitForFSEvent()
+	}
+	if wasWatching {
+		// The old watcher's listener is gone, polling takes over
+		return m.statWatch()
+	}
+	return nil
+}
+
+// fsDebounce is how long file system events are collected before rebuilding
+const fsDebounce = 200 * time.Millisecond
+
+// waitForFSEvent waits for the next change reported by the file watcher
+func (m model) waitForFSEvent() tea.Cmd {
+	if m.watcher == nil {
+		return nil
+	}
+	changes := m.watcher.Changes
+	return func() tea.Msg {
+		<-changes
+		return fsEventMsg{}
+	}
+}
+
+// statWatch schedules a stat of the visible directories, or nothing if disabled
+// or the file watcher is running
+func (m model) statWatch() tea.Cmd {
+	if m.watchInterval <= 0 || m.watcher != nil {
+		return nil
+	}
+	dirs := []string{m.rootPath}
+	for _, dir := range m.dirMap {
+		dirs = append(dirs, filepath.Join(m.rootPath, dir))
+	}
+	return tea.Tick(m.watchInterval, func(time.Time) tea.Msg {
+		return dirStatMsg{mtimes: internal.StatDirs(dirs)}
+	})
+}
+
+// tick schedules the next background refresh, or nothing if disabled
+func tick(interval time.Duration, gen int) tea.Cmd {
+	if interval <= 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return tickMsg{gen: gen}
+	})
+}
+
+// parseRefreshInterval parses a refresh interval such as "30s", "2m" or plain
+// seconds; "off" or "0" disables automatic refresh
+func parseRefreshInterval(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(strings.ToLower(value))
+	switch value {
+	case "":
+		return 0, false
+	case "off", "none", "0":
+		return 0, true
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if interval, err := time.ParseDuration(value); err == nil && interval > 0 {
+		return interval, true
+	}
+	return 0, false
+}
 
-		helpStyle := lipgloss.NewStyle().
-			Padding(2, 4).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("62"))
+// refreshIntervalSetting resolves the refresh interval from VINW_REFRESH_INTERVAL,
+// then the config file, then the default
+func refreshIntervalSetting(config *internal.Config) time.Duration {
+	if interval, ok := parseRefreshInterval(os.Getenv("VINW_REFRESH_INTERVAL")); ok {
+		return interval
+	}
+	if interval, ok := parseRefreshInterval(config.String("refresh_interval", "")); ok {
+		return interval
+	}
+	return defaultRefreshInterval
+}
 
-		return lipgloss.Place(
-			m.width,
-			m.height,
-			lipgloss.Center,
-			lipgloss.Center,
-			helpStyle.Render(helpText),
-		)
+// watchIntervalSetting resolves how often directories are checked for added or
+// removed entries, from VINW_WATCH_INTERVAL or the config file
+func watchIntervalSetting(config *internal.Config) time.Duration {
+	if interval, ok := parseRefreshInterval(os.Getenv("VINW_WATCH_INTERVAL")); ok {
+		return interval
+	}
+	if interval, ok := parseRefreshInterval(config.String("watch_interval", "")); ok {
+		return interval
 	}
+	return defaultWatchInterval
+}
 
-	return fmt.Sprintf("%s\n%s\n%s", m.headerView(), m.viewport.View(), m.footerView())
+// slowDirThresholdSetting resolves how long a directory read may take before
+// vinw stops auto-expanding it, from VINW_SLOW_DIR_THRESHOLD or the config file
+func slowDirThresholdSetting(config *internal.Config) time.Duration {
+	if threshold, ok := parseRefreshInterval(os.Getenv("VINW_SLOW_DIR_THRESHOLD")); ok {
+		return threshold
+	}
+	if threshold, ok := parseRefreshInterval(config.String("slow_dir_threshold", "")); ok {
+		return threshold
+	}
+	return defaultSlowDirThreshold
 }
 
-func shortenPath(path string) string {
-	home := os.Getenv("HOME")
-	if home != "" && strings.HasPrefix(path, home) {
-		return "~" + strings.TrimPrefix(path, home)
+// selectionModified shows when the selected entry was last modified, for the
+// footer. Only the selected path is stat'ed, once per render.
+func (m model) selectionModified() string {
+	relPath, ok := m.fileMap[m.selectedLine]
+	if !ok {
+		if relPath, ok = m.dirMap[m.selectedLine]; !ok {
+			return ""
+		}
 	}
-	return path
+	info, err := os.Stat(filepath.Join(m.rootPath, relPath))
+	if err != nil {
+		return ""
+	}
+	return " | modified " + humanizeTime(info.ModTime())
 }
 
-func (m model) headerView() string {
-	shortPath := shortenPath(m.rootPath)
-	title := fmt.Sprintf("ⓥⓘⓝⓦ - %s", shortPath)
+// humanizeTime renders how long ago t was, coarsely (3m ago, 2d ago)
+func humanizeTime(t time.Time) string {
+	elapsed := time.Since(t)
+	switch {
+	case elapsed < time.Minute:
+		return "just now"
+	case elapsed < time.Hour:
+		return fmt.Sprintf("%dm ago", int(elapsed.Minutes()))
+	case elapsed < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(elapsed.Hours()))
+	case elapsed < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(elapsed.Hours()/24))
+	case elapsed < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(elapsed.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy ago", int(elapsed.Hours()/(24*365)))
+	}
+}
 
-	// Add copy hint if active
-	if m.showCopyHint {
-		copyHintStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("42")). // Green
-			Bold(true)
-		hint := copyHintStyle.Render(fmt.Sprintf(" [Copied: %s]", m.copiedPath))
-		title = title + hint
+// formatSize renders a byte count compactly for the tree (340B, 1.2K, 5.1M)
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
 	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(size)/float64(div), "KMGTPE"[exp])
+}
 
-	// Use theme colors for header
-	themedHeaderStyle := m.theme.CreateHeaderStyle()
-	return themedHeaderStyle.Width(m.width).Render(title)
+// parseSince parses a --since window, accepting Go durations plus whole days ("7d")
+func parseSince(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	window, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return window, nil
 }
 
-func (m model) footerView() string {
-	ignoreStatus := "OFF"
-	if m.respectIgnore {
-		ignoreStatus = "ON"
+// listChangedFiles prints the files with uncommitted changes, filtered by the same
+// gitignore, .vinwignore and hidden file settings as the tree, and returns the exit code
+func listChangedFiles(watchPath, separator string) int {
+	if !internal.IsGitRepo(watchPath) {
+		fmt.Fprintf(os.Stderr, "Not a git repository: %s\n", watchPath)
+		return 1
 	}
-	hiddenStatus := "OFF"
-	if m.showHidden {
-		hiddenStatus = "ON"
+
+	// Match the tree: global defaults, then this directory's saved view settings
+	config, _ := internal.LoadConfigFor(watchPath)
+	settings := model{}
+	settings.applyViewSettings(fmt.Sprintf("hidden=%t", config.Bool("show_hidden", false)))
+	settings.applyViewSettings(internal.GetSessionValue("view-settings", generateSessionID(watchPath)))
+	opts := treeOptions{
+		gitignore:     internal.NewGitIgnore(watchPath),
+		vinwignore:    internal.NewVinwIgnore(watchPath),
+		alwaysSkip:    config.StringList("always_skip", nil),
+		respectIgnore: true,
+		showHidden:    settings.showHidden,
 	}
-	nestStatus := "OFF"
-	if m.nestingEnabled {
-		nestStatus = "ON"
+
+	// git reports paths from the repo root, list them relative to the watched
+	// directory and leave out changes outside it
+	if absPath, err := filepath.Abs(watchPath); err == nil {
+		watchPath = absPath
 	}
-	// Three lines for skinny layout
-	line1 := fmt.Sprintf("j/k: nav | h/l: collapse/expand | u: hidden [%s] | r/R: refresh", hiddenStatus)
-	line2 := fmt.Sprintf("i: git [%s] | n: nesting [%s] | t/T: theme [%s]", ignoreStatus, nestStatus, m.theme.Current.Name)
-	line3 := "a: new file | A: new dir | d: delete | c: copy path | space/enter: select | ?: help | q: quit"
-	info := line1 + "\n" + line2 + "\n" + line3
-	return footerStyle.Width(m.width).Render(info)
+	repoRoot := internal.GetRepoRoot(watchPath)
+	var changed []string
+	for repoPath := range internal.DiffCounts(internal.GetGitDiffStatsIn(watchPath)) {
+		relPath, err := filepath.Rel(watchPath, filepath.Join(repoRoot, filepath.FromSlash(repoPath)))
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		// Skip the file if it, or any directory above it, is hidden from the tree
+		visible := true
+		fullPath := watchPath
+		for _, part := range strings.Split(relPath, string(filepath.Separator)) {
+			fullPath = filepath.Join(fullPath, part)
+			if opts.skipEntry(fullPath, part) {
+				visible = false
+				break
+			}
+		}
+		if visible {
+			changed = append(changed, relPath)
+		}
+	}
+	sort.Strings(changed)
+
+	for _, relPath := range changed {
+		fmt.Print(relPath + separator)
+	}
+	return 0
+}
+
+// benchmarkReport holds the results of --benchmark, durations in milliseconds
+type benchmarkReport struct {
+	Directory       string    `json:"directory"`
+	TotalFiles      int       `json:"total_files"`
+	GitDiffMs       float64   `json:"git_diff_ms"`
+	ChangedFiles    int       `json:"changed_files"`
+	TreeBuildMs     []float64 `json:"tree_build_ms"`
+	TreeBuildAvgMs  float64   `json:"tree_build_avg_ms"`
+	TreeLines       int       `json:"tree_lines"`
+	TreeStringMs    float64   `json:"tree_string_ms"`
+	RenderMs        float64   `json:"render_ms"`
+	IgnorePaths     int       `json:"ignore_paths"`
+	IgnoredPaths    int       `json:"ignored_paths"`
+	IgnoreMs        float64   `json:"ignore_ms"`
+	MemorySysMiB    float64   `json:"memory_sys_mib"`
+	HeapInUseMiB    float64   `json:"heap_in_use_mib"`
+	TotalAllocMiB   float64   `json:"total_alloc_mib"`
+	GarbageCollects uint32    `json:"gc_count"`
+}
+
+// ms converts a duration to fractional milliseconds
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
 
-func tick() tea.Cmd {
-	// Reduced frequency: manual refresh with 'r' key is preferred for performance
-	return tea.Tick(60*time.Second, func(t time.Time) tea.Msg {
-		return tickMsg(t)
+// runBenchmark times git diff, tree building, rendering and gitignore matching
+func runBenchmark(watchPath string, gitignore, vinwignore *internal.GitIgnore) benchmarkReport {
+	report := benchmarkReport{Directory: watchPath}
+
+	// Count files, keeping every path for the gitignore benchmark
+	var allPaths []string
+	filepath.Walk(watchPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		allPaths = append(allPaths, path)
+		if !info.IsDir() {
+			report.TotalFiles++
+		}
+		return nil
+	})
+
+	// Benchmark git diff
+	start := time.Now()
+	diffCache := internal.GetAllGitDiffs()
+	report.GitDiffMs = ms(time.Since(start))
+	report.ChangedFiles = len(diffCache)
+
+	// Benchmark tree building (3 runs for average)
+	var total time.Duration
+	for i := 0; i < 3; i++ {
+		start = time.Now()
+		_, _ = buildTreeWithMaps(watchPath, treeOptions{
+			diffCache:     diffCache,
+			gitignore:     gitignore,
+			vinwignore:    vinwignore,
+			respectIgnore: true,
+			expandedDirs:  make(map[string]bool),
+		})
+		elapsed := time.Since(start)
+		total += elapsed
+		report.TreeBuildMs = append(report.TreeBuildMs, ms(elapsed))
+	}
+	report.TreeBuildAvgMs = ms(total / 3)
+
+	// Benchmark rendering of a fully nested tree with a selection
+	fullTree, _ := buildTreeWithMaps(watchPath, treeOptions{
+		diffCache:      diffCache,
+		gitignore:      gitignore,
+		vinwignore:     vinwignore,
+		respectIgnore:  true,
+		nestingEnabled: true,
+		expandedDirs:   make(map[string]bool),
 	})
+	start = time.Now()
+	lines := strings.Split(fullTree.String(), "\n")
+	report.TreeStringMs = ms(time.Since(start))
+	report.TreeLines = len(lines)
+	start = time.Now()
+	renderTreeWithSelectionOptimized(lines, len(lines)/2)
+	report.RenderMs = ms(time.Since(start))
+
+	// Benchmark gitignore matching over every path in the directory
+	start = time.Now()
+	for _, path := range allPaths {
+		if gitignore.IsIgnored(path) {
+			report.IgnoredPaths++
+		}
+	}
+	report.IgnoreMs = ms(time.Since(start))
+	report.IgnorePaths = len(allPaths)
+
+	// Memory: Sys is what the runtime obtained from the OS, the closest to a peak
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	report.MemorySysMiB = float64(mem.Sys) / (1 << 20)
+	report.HeapInUseMiB = float64(mem.HeapAlloc) / (1 << 20)
+	report.TotalAllocMiB = float64(mem.TotalAlloc) / (1 << 20)
+	report.GarbageCollects = mem.NumGC
+
+	return report
+}
+
+// print writes the human-readable benchmark report
+func (r benchmarkReport) print(w io.Writer) {
+	fmt.Fprintf(w, "\n=== vinw Performance Benchmark ===\n")
+	fmt.Fprintf(w, "Directory: %s\n", r.Directory)
+	fmt.Fprintf(w, "Total files: %d\n\n", r.TotalFiles)
+
+	fmt.Fprintf(w, "Git diff time: %.3fms\n", r.GitDiffMs)
+	fmt.Fprintf(w, "Files with changes: %d\n\n", r.ChangedFiles)
+
+	for i, t := range r.TreeBuildMs {
+		fmt.Fprintf(w, "Tree build #%d: %.3fms\n", i+1, t)
+	}
+	fmt.Fprintf(w, "Average tree build: %.3fms\n\n", r.TreeBuildAvgMs)
+
+	fmt.Fprintf(w, "Tree lines (nested): %d\n", r.TreeLines)
+	fmt.Fprintf(w, "Tree to string: %.3fms\n", r.TreeStringMs)
+	fmt.Fprintf(w, "Render with selection: %.3fms\n\n", r.RenderMs)
+
+	perPath := 0.0
+	if r.IgnorePaths > 0 {
+		perPath = r.IgnoreMs * 1000 / float64(r.IgnorePaths)
+	}
+	fmt.Fprintf(w, "Gitignore matching: %.3fms for %d paths (%.2fµs/path, %d ignored)\n\n", r.IgnoreMs, r.IgnorePaths, perPath, r.IgnoredPaths)
+
+	fmt.Fprintf(w, "Memory (peak from OS): %.1f MiB\n", r.MemorySysMiB)
+	fmt.Fprintf(w, "Heap in use: %.1f MiB\n", r.HeapInUseMiB)
+	fmt.Fprintf(w, "Total allocated: %.1f MiB (%d GCs)\n\n", r.TotalAllocMiB, r.GarbageCollects)
+
+	fmt.Fprintf(w, "=== Benchmark Complete ===\n")
 }
 
 // buildTree recursively builds a file tree with git diff tracking
@@ -1156,32 +4801,90 @@ func buildTreeWithOptions(rootPath string, diffCache map[string]int, gitignore *
 
 // buildTreeWithMap builds tree and returns a map of line numbers to file paths (deprecated, use buildTreeWithMaps)
 func buildTreeWithMap(rootPath string, diffCache map[string]int, gitignore *internal.GitIgnore, respectIgnore bool, nestingEnabled bool) (*tree.Tree, map[int]string) {
-	fileMap := make(map[int]string)
-	lineNum := 1 // Start at 1 because the root directory takes line 0
-	visited := newVisitedPaths() // Track visited paths for symlink loop detection
-	t := buildTreeRecursiveWithMap(rootPath, "", diffCache, gitignore, respectIgnore, nestingEnabled, make(map[string]bool), false, &lineNum, fileMap, nil, visited, 0)
-	return t, fileMap
+	t, maps := buildTreeWithMaps(rootPath, treeOptions{
+		diffCache:      diffCache,
+		gitignore:      gitignore,
+		respectIgnore:  respectIgnore,
+		nestingEnabled: nestingEnabled,
+		expandedDirs:   make(map[string]bool),
+	})
+	return t, maps.fileMap
 }
 
-// buildTreeWithMaps builds tree and returns maps of line numbers to file paths and directory paths
-func buildTreeWithMaps(rootPath string, diffCache map[string]int, gitignore *internal.GitIgnore, respectIgnore bool, nestingEnabled bool, expandedDirs map[string]bool, showHidden bool) (*tree.Tree, map[int]string, map[int]string) {
-	fileMap := make(map[int]string)
-	dirMap := make(map[int]string)
-	lineNum := 1 // Start at 1 because the root directory takes line 0
+// buildTreeWithMaps builds tree and returns maps of line numbers to file, directory and ghost paths
+func buildTreeWithMaps(rootPath string, opts treeOptions) (*tree.Tree, treeMaps) {
+	maps := treeMaps{
+		fileMap:    make(map[int]string),
+		dirMap:     make(map[int]string),
+		ghostMap:   make(map[int]string),
+		typeCounts: make(map[string]int),
+		excluded:   make(map[string]int),
+	}
+	lineNum := 1                 // Start at 1 because the root directory takes line 0
 	visited := newVisitedPaths() // Track visited paths for symlink loop detection
-	t := buildTreeRecursiveWithMap(rootPath, "", diffCache, gitignore, respectIgnore, nestingEnabled, expandedDirs, showHidden, &lineNum, fileMap, dirMap, visited, 0)
-	return t, fileMap, dirMap
+	t := buildTreeRecursiveWithMap(rootPath, "", &opts, &lineNum, &maps, visited, 0)
+	return t, maps
+}
+
+// treeContent renders the cached tree lines with the selection highlighted,
+// wrapping long rows when wrapNames is on
+func (m *model) treeContent() string {
+	if !m.wrapNames {
+		m.wrapRows = nil
+		return renderTreeWithSelectionOptimized(m.treeLines, m.selectedLine)
+	}
+
+	highlightStyle := lipgloss.NewStyle().Reverse(true)
+	m.wrapRows = make([]int, 0, len(m.treeLines)+1)
+	rows := make([]string, 0, len(m.treeLines))
+	for i, line := range m.treeLines {
+		m.wrapRows = append(m.wrapRows, len(rows))
+		for _, row := range wrapTreeLine(line, m.viewport.Width) {
+			if i == m.selectedLine {
+				row = highlightStyle.Render(row)
+			}
+			rows = append(rows, row)
+		}
+	}
+	m.wrapRows = append(m.wrapRows, len(rows))
+	return strings.Join(rows, "\n")
 }
 
-// renderTreeWithSelection renders tree with highlighted selected line
-func renderTreeWithSelection(content string, selectedLine int) string {
-	lines := strings.Split(content, "\n")
-	if selectedLine >= 0 && selectedLine < len(lines) {
-		// Highlight selected line with inverse colors
-		highlightStyle := lipgloss.NewStyle().Reverse(true)
-		lines[selectedLine] = highlightStyle.Render(lines[selectedLine])
+// wrapTreeLine splits a rendered tree line wider than width into rows, with
+// continuation rows indented under the name and the tree guides carried down
+func wrapTreeLine(line string, width int) []string {
+	if width <= 0 || ansi.StringWidth(line) <= width {
+		return []string{line}
+	}
+
+	// The prefix is the run of tree guides before the name
+	plain := ansi.Strip(line)
+	prefixWidth := 0
+	var guide strings.Builder
+	for _, r := range plain {
+		if !strings.ContainsRune("│├└─ ", r) {
+			break
+		}
+		prefixWidth++
+		if r == '│' || r == '├' {
+			guide.WriteRune('│')
+		} else {
+			guide.WriteRune(' ')
+		}
+	}
+	// Too little room for the name, leave it to the viewport
+	if width-prefixWidth < 8 {
+		return []string{line}
+	}
+
+	prefix := ansi.Cut(line, 0, prefixWidth)
+	wrapped := ansi.Hardwrap(ansi.Cut(line, prefixWidth, ansi.StringWidth(line)), width-prefixWidth, true)
+	rows := strings.Split(wrapped, "\n")
+	rows[0] = prefix + rows[0]
+	for i := 1; i < len(rows); i++ {
+		rows[i] = normalStyle.Render(guide.String()) + rows[i]
 	}
-	return strings.Join(lines, "\n")
+	return rows
 }
 
 // renderTreeWithSelectionOptimized works with cached lines for better performance
@@ -1205,13 +4908,16 @@ func renderTreeWithSelectionOptimized(lines []string, selectedLine int) string {
 	return strings.Join(result, "\n")
 }
 
-func buildTreeRecursiveWithMap(path string, relativePath string, diffCache map[string]int, gitignore *internal.GitIgnore, respectIgnore bool, nestingEnabled bool, expandedDirs map[string]bool, showHidden bool, lineNum *int, fileMap map[int]string, dirMap map[int]string, visited *visitedPaths, depth int) *tree.Tree {
+// maxDepth caps how deep the builder recurses, guarding against deep symlink chains
+const maxDepth = 10
+
+func buildTreeRecursiveWithMap(path string, relativePath string, opts *treeOptions, lineNum *int, maps *treeMaps, visited *visitedPaths, depth int) *tree.Tree {
 	dirName := filepath.Base(path)
 	t := tree.Root(dirName)
 
 	// Check max depth (prevent extremely deep symlink chains)
-	const maxDepth = 10
 	if depth > maxDepth {
+		maps.depthLimited++
 		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("yellow"))
 		t.Child(warningStyle.Render("⚠ Max depth reached"))
 		return t
@@ -1225,33 +4931,42 @@ func buildTreeRecursiveWithMap(path string, relativePath string, diffCache map[s
 		return t
 	}
 
+	readStart := time.Now()
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return t
 	}
 
+	// Stop auto-expanding directories that are slow to read (network mounts, FUSE)
+	if opts.nestingEnabled && opts.slowThreshold > 0 && relativePath != "" && time.Since(readStart) > opts.slowThreshold {
+		if _, known := opts.slowDirs[relativePath]; !known && opts.slowDirs != nil {
+			opts.slowDirs[relativePath] = true
+			return t
+		}
+	}
+	opts.sortEntries(entries, path, relativePath)
+
 	for _, entry := range entries {
 		fullPath := filepath.Join(path, entry.Name())
 		relPath := filepath.Join(relativePath, entry.Name())
 		entryName := entry.Name()
 
-		// Always skip .git directory
-		if entryName == ".git" {
+		// Skip .git, hidden and ignored entries, counting the user's exclusions
+		if reason := opts.skipReason(fullPath, entryName); reason != "" {
+			if reason == ".vinwignore" || reason == "always_skip" {
+				maps.excluded[reason]++
+			}
 			continue
 		}
 
-		// Check if this entry is hidden
-		isHidden := strings.HasPrefix(entryName, ".")
-		isGitignore := entryName == ".gitignore"
-
-		// Skip hidden files and folders unless showHidden is enabled
-		// Always show .gitignore regardless of showHidden setting
-		if isHidden && !isGitignore && !showHidden {
+		// A combined view only shows the given roots and the directories leading to them
+		if !opts.inRoots(relPath) {
 			continue
 		}
 
-		// Check gitignore if enabled
-		if respectIgnore && gitignore != nil && gitignore.IsIgnored(fullPath) {
+		// Folder-only view skips files, including links to files
+		if opts.dirsOnly && !entry.IsDir() && !(isSymlink(entry) && func() bool { isDir, _, _ := isSymlinkToDir(fullPath); return isDir }()) {
+			maps.filteredFiles++
 			continue
 		}
 
@@ -1280,20 +4995,21 @@ func buildTreeRecursiveWithMap(path string, relativePath string, diffCache map[s
 				displayName := entryName + " → " + targetPath + "/"
 
 				// Track in dirMap
-				if dirMap != nil {
-					dirMap[*lineNum] = relPath
+				if maps.dirMap != nil {
+					maps.dirMap[*lineNum] = relPath
 				}
 				*lineNum++
 
-				// Allow expansion like normal directories
-				shouldExpand := nestingEnabled || (expandedDirs != nil && expandedDirs[relPath])
+				// Allow expansion like normal directories, unless links aren't followed
+				shouldExpand := opts.nestingEnabled || (opts.expandedDirs != nil && opts.expandedDirs[relPath]) || opts.leadsToRoot(relPath)
+				if opts.skipSymlinkDirs {
+					shouldExpand = false
+				}
 
 				if shouldExpand {
 					// Recursively build (with loop protection and increased depth)
 					subTree := buildTreeRecursiveWithMap(
-						fullPath, relPath, diffCache, gitignore,
-						respectIgnore, nestingEnabled, expandedDirs,
-						showHidden, lineNum, fileMap, dirMap, visited, depth+1,
+						fullPath, relPath, opts, lineNum, maps, visited, depth+1,
 					)
 					// Style the root with symlink indicator
 					styledRoot := symlinkStyle.Render(displayName)
@@ -1306,45 +5022,30 @@ func buildTreeRecursiveWithMap(path string, relativePath string, diffCache map[s
 							subFullPath := filepath.Join(fullPath, subEntry.Name())
 							subRelPath := filepath.Join(relPath, subEntry.Name())
 
-							if subEntry.Name() == ".git" {
-								continue
-							}
-
-							subIsHidden := strings.HasPrefix(subEntry.Name(), ".")
-							if subIsHidden && subEntry.Name() != ".gitignore" && !showHidden {
-								continue
-							}
-
-							if respectIgnore && gitignore != nil && gitignore.IsIgnored(subFullPath) {
+							if opts.skipEntry(subFullPath, subEntry.Name()) {
 								continue
 							}
 
 							if subEntry.IsDir() || (isSymlink(subEntry) && func() bool { isDir, _, _ := isSymlinkToDir(subFullPath); return isDir }()) {
 								subTreeChild := buildTreeRecursiveWithMap(
-									subFullPath, subRelPath, diffCache, gitignore,
-									respectIgnore, nestingEnabled, expandedDirs,
-									showHidden, lineNum, fileMap, dirMap, visited, depth+1,
+									subFullPath, subRelPath, opts, lineNum, maps, visited, depth+1,
 								)
+								subTreeChild.Root(dirStyle.Render(subEntry.Name() + "/"))
 								subTree.Child(subTreeChild)
+							} else if opts.dirsOnly {
+								maps.filteredFiles++
 							} else {
 								// File handling
-								fileMap[*lineNum] = subRelPath
+								maps.fileMap[*lineNum] = subRelPath
 								*lineNum++
-
-								var diffLines int
-								if diffCache != nil {
-									diffLines = diffCache[subRelPath]
-								}
+								maps.countType(subEntry.Name())
 
 								fileStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
 								name := fileStyle.Render(subEntry.Name())
 
-								if diffLines > 0 {
-									diffStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-									name = name + diffStyle.Render(fmt.Sprintf(" (+%d)", diffLines))
-								} else if diffLines == -1 {
-									diffStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-									name = name + diffStyle.Render(" (new)")
+								if marker := opts.diffMarkerText(subRelPath); marker != "" {
+									diffStyle := lipgloss.NewStyle().Foreground(opts.diffMarkerColor(subRelPath))
+									name = name + diffStyle.Render(marker)
 								}
 
 								subTree.Child(name)
@@ -1359,22 +5060,24 @@ func buildTreeRecursiveWithMap(path string, relativePath string, diffCache map[s
 			} else {
 				// Symlinked file
 				displayName := entryName + " → " + targetPath
-				fileMap[*lineNum] = relPath
+				maps.fileMap[*lineNum] = relPath
 				*lineNum++
+				maps.countType(entryName)
 
 				// Check for git diff on symlinked file
-				var diffLines int
-				if diffCache != nil {
-					diffLines = diffCache[relPath]
+				name := symlinkStyle.Render(displayName)
+
+				// Size the link by its target, which is what the viewer shows
+				if opts.sizeIndicator != nil {
+					if info, err := os.Stat(fullPath); err == nil && info.Mode().IsRegular() {
+						lines := opts.lineCounts.Count(fullPath, info.ModTime())
+						name = opts.sizeIndicator.Indicator(lines) + " " + name
+					}
 				}
 
-				name := symlinkStyle.Render(displayName)
-				if diffLines > 0 {
-					diffStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-					name = name + diffStyle.Render(fmt.Sprintf(" (+%d)", diffLines))
-				} else if diffLines == -1 {
-					diffStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
-					name = name + diffStyle.Render(" (new)")
+				if marker := opts.diffMarkerText(relPath); marker != "" {
+					diffStyle := lipgloss.NewStyle().Foreground(opts.diffMarkerColor(relPath))
+					name = name + diffStyle.Render(marker)
 				}
 
 				t.Child(name)
@@ -1385,54 +5088,105 @@ func buildTreeRecursiveWithMap(path string, relativePath string, diffCache map[s
 		// Regular file or directory (not a symlink)
 		if entry.IsDir() {
 			// Track directory in dirMap at current line
-			if dirMap != nil {
-				dirMap[*lineNum] = relPath
+			if maps.dirMap != nil {
+				maps.dirMap[*lineNum] = relPath
 			}
 			*lineNum++
 
 			// Determine if we should expand this directory
-			shouldExpand := nestingEnabled || (expandedDirs != nil && expandedDirs[relPath])
+			shouldExpand := opts.nestingEnabled || (opts.expandedDirs != nil && opts.expandedDirs[relPath]) || opts.leadsToRoot(relPath)
 
-			if shouldExpand {
+			var subTree *tree.Tree
+			if shouldExpand && !opts.slowDirs[relPath] {
 				// Recursively build subtree - showHidden MUST be passed through
-				subTree := buildTreeRecursiveWithMap(fullPath, relPath, diffCache, gitignore, respectIgnore, nestingEnabled, expandedDirs, showHidden, lineNum, fileMap, dirMap, visited, depth+1)
+				subTree = buildTreeRecursiveWithMap(fullPath, relPath, opts, lineNum, maps, visited, depth+1)
+			}
+
+			// Directories read "name/" in the directory color, expanded or not
+			dirNameStyled := dirStyle.Render(entryName + "/")
+			if opts.slowDirs[relPath] {
+				// Slow directory, left unread until explicitly loaded
+				maps.slowSkipped++
+				slowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+				t.Child(dirNameStyled + slowStyle.Render(" (slow, press l to load)"))
+			} else if subTree != nil {
+				// Mark expanded directories with nothing visible inside (files count in folder-only view)
+				if subTree.Children().Length() == 0 && (!opts.dirsOnly || opts.isEmptyDir(fullPath)) {
+					dirNameStyled += normalStyle.Render(" (empty)")
+				}
+				subTree.Root(dirNameStyled)
 				t.Child(subTree)
 			} else {
 				// Show collapsed directory (including hidden directories when showHidden is true)
-				dirStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("147"))
-				displayName := entryName + "/"
-				dirNameStyled := dirStyle.Render(displayName)
+				// Mark directories with nothing visible inside, otherwise hint at their size
+				if count, ok := opts.childCount(fullPath, relPath); ok && count == 0 {
+					dirNameStyled += normalStyle.Render(" (empty)")
+				} else if ok {
+					dirNameStyled += normalStyle.Render(fmt.Sprintf(" (%d)", count))
+				}
 				t.Child(dirNameStyled)
 			}
 		} else {
 			// Track file in fileMap at current line number
-			fileMap[*lineNum] = relPath
+			maps.fileMap[*lineNum] = relPath
 			*lineNum++
-
-			// Get git diff lines from cache
-			var diffLines int
-			if diffCache != nil {
-				diffLines = diffCache[relPath]
-			}
+			maps.countType(entryName)
 
 			// Style filename (including hidden files when showHidden is true)
 			fileStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+			var info os.FileInfo
+			if opts.sizeIndicator != nil || opts.fileSizes || !opts.recentSince.IsZero() {
+				info, _ = entry.Info()
+			}
+
+			// Recently modified files (--since) stand out regardless of git status
+			if info != nil && !opts.recentSince.IsZero() && info.ModTime().After(opts.recentSince) {
+				fileStyle = fileStyle.Foreground(lipgloss.Color("81"))
+			}
 			name := fileStyle.Render(entryName)
 
+			// Mark the file currently shown in the paired viewer
+			if relPath == opts.viewingFile {
+				name = viewingStyle.Render("◉ ") + name
+			}
+
+			// Prefix with the line-count indicator when enabled
+			if opts.sizeIndicator != nil && info != nil {
+				lines := opts.lineCounts.Count(fullPath, info.ModTime())
+				name = opts.sizeIndicator.Indicator(lines) + " " + name
+			}
+
+			// Dim size after the name, before the diff marker
+			if opts.fileSizes && info != nil {
+				name += normalStyle.Render(" " + formatSize(info.Size()))
+			}
+
 			// Add diff indicator if file has changes
-			if diffLines > 0 {
-				diffStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42")) // Green
-				name = name + diffStyle.Render(fmt.Sprintf(" (+%d)", diffLines))
-			} else if diffLines == -1 {
-				// New untracked file (marked as -1 to avoid expensive line counting)
-				diffStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42")) // Green
-				name = name + diffStyle.Render(" (new)")
+			if marker := opts.diffMarkerText(relPath); marker != "" {
+				diffStyle := lipgloss.NewStyle().Foreground(opts.diffMarkerColor(relPath))
+				name = name + diffStyle.Render(marker)
 			}
 
 			t.Child(name)
 		}
 	}
 
+	// Ghost entries for tracked files deleted from the working tree
+	for _, ghostPath := range opts.deletedFiles[relativePath] {
+		if opts.dirsOnly {
+			break
+		}
+		maps.ghostMap[*lineNum] = ghostPath
+		*lineNum++
+
+		displayName, err := filepath.Rel(relativePath, ghostPath)
+		if err != nil {
+			displayName = ghostPath
+		}
+		deletedStyle := lipgloss.NewStyle().Foreground(gitStatusColors[internal.StatusDeleted])
+		t.Child(ghostStyle.Render(displayName) + deletedStyle.Render(" (deleted)"))
+	}
+
 	return t
 }
 
@@ -1497,6 +5251,45 @@ func buildTreeRecursive(path string, relativePath string, diffCache map[string]i
 	return t
 }
 
+// heartbeatInterval is how often a running vinw marks its session as live;
+// a heartbeat older than heartbeatStale means the session's owner is gone
+const (
+	heartbeatInterval = 10 * time.Second
+	heartbeatStale    = 3 * heartbeatInterval
+)
+
+// writeHeartbeat records that this process owns the session
+func writeHeartbeat(sessionID string) {
+	internal.SetSessionValue("heartbeat", sessionID, fmt.Sprintf("%d %d", time.Now().Unix(), os.Getpid()))
+}
+
+// heartbeat schedules the next session heartbeat
+func heartbeat() tea.Cmd {
+	return tea.Tick(heartbeatInterval, func(t time.Time) tea.Msg {
+		return heartbeatMsg{}
+	})
+}
+
+// sessionInUse reports whether another live vinw process owns the session
+func sessionInUse(sessionID string) bool {
+	var stamp int64
+	var pid int
+	if _, err := fmt.Sscanf(internal.GetSessionValue("heartbeat", sessionID), "%d %d", &stamp, &pid); err != nil {
+		return false
+	}
+	return pid != os.Getpid() && time.Since(time.Unix(stamp, 0)) < heartbeatStale
+}
+
+// availableSessionID suffixes the directory's session ID while other instances
+// hold it, so two vinw windows on one directory don't share viewer state
+func availableSessionID(base string) string {
+	sessionID := base
+	for n := 2; sessionInUse(sessionID); n++ {
+		sessionID = fmt.Sprintf("%s-%d", base, n)
+	}
+	return sessionID
+}
+
 // generateSessionID creates a unique session ID based on the current directory
 func generateSessionID(path string) string {
 	// Use absolute path to ensure consistency
@@ -1508,142 +5301,327 @@ func generateSessionID(path string) string {
 }
 
 func main() {
-	// Check for benchmark mode
+	// Parse flags and the optional watch path (defaults to current directory)
 	benchmarkMode := false
-	if len(os.Args) > 1 && os.Args[1] == "--benchmark" {
-		benchmarkMode = true
-		if len(os.Args) > 2 {
-			os.Chdir(os.Args[2])
+	dryRun := false
+	jsonOutput := false // Only used with --benchmark
+	noFollowSymlinks := false
+	countNewFlag := false // Count lines of untracked files
+	flatView := false
+	startOnChanges := false // Land on the first changed file
+	listChanged := false    // Print changed files and exit
+	githubSetup := false    // Run the GitHub setup and exit (used by H)
+	nullSeparated := false  // NUL-separate --list-changed output
+	watchPath := "."
+	var watchPaths []string // More than one builds a combined view
+	var recentWindow time.Duration
+	logPath := "" // Debug event log, off unless --log is given
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if value, ok := strings.CutPrefix(arg, "--log="); ok || arg == "--log" {
+			if !ok {
+				if i+1 >= len(args) {
+					fmt.Fprintln(os.Stderr, "--log needs a file path, e.g. --log vinw.log")
+					os.Exit(1)
+				}
+				i++
+				value = args[i]
+			}
+			logPath = value
+			continue
+		}
+		if value, ok := strings.CutPrefix(arg, "--since="); ok || arg == "--since" {
+			if !ok {
+				if i+1 >= len(args) {
+					fmt.Fprintln(os.Stderr, "--since needs a duration, e.g. --since 24h")
+					os.Exit(1)
+				}
+				i++
+				value = args[i]
+			}
+			window, err := parseSince(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid --since duration %q: %v\n", value, err)
+				os.Exit(1)
+			}
+			recentWindow = window
+			continue
 		}
+		switch arg {
+		case "--benchmark":
+			benchmarkMode = true
+		case "--json":
+			jsonOutput = true
+		case "--dry-run":
+			dryRun = true
+		case "--no-follow-symlinks":
+			noFollowSymlinks = true
+		case "--count-new":
+			countNewFlag = true
+		case "--flat":
+			flatView = true
+		case "--changes":
+			startOnChanges = true
+		case "--list-changed":
+			listChanged = true
+		case "--null":
+			nullSeparated = true
+		case "--github-setup":
+			githubSetup = true
+		default:
+			if i == 0 && arg == "changed" {
+				// `vinw changed` unless there's a directory by that name
+				if info, err := os.Stat(arg); err != nil || !info.IsDir() {
+					listChanged = true
+					continue
+				}
+			}
+			watchPaths = append(watchPaths, arg)
+		}
+	}
+	if len(watchPaths) > 0 {
+		watchPath = watchPaths[0]
 	}
 
-	// Get watch path from args or use current directory
-	watchPath := "."
-	if len(os.Args) > 1 && os.Args[1] != "--benchmark" {
-		watchPath = os.Args[1]
+	// Several directories share one tree rooted at their common parent
+	var roots []string
+	if len(watchPaths) > 1 {
+		for _, path := range watchPaths {
+			absRoot, _ := filepath.Abs(path)
+			if info, err := os.Stat(absRoot); err != nil || !info.IsDir() {
+				fmt.Fprintf(os.Stderr, "Not a directory: %s\n", path)
+				os.Exit(1)
+			}
+			roots = append(roots, absRoot)
+		}
+		watchPath = commonParent(roots)
+	}
+
+	if logPath != "" {
+		closeLog, err := internal.OpenEventLog(logPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't write log: %v\n", err)
+			os.Exit(1)
+		}
+		defer closeLog()
 	}
 
 	// Get absolute path for everything
 	absPath, _ := filepath.Abs(watchPath)
 	watchPath = absPath // Use absolute path everywhere
 
+	// Benchmark and list modes run from the target directory so git sees the right repo
+	if benchmarkMode || listChanged || githubSetup {
+		os.Chdir(absPath)
+	}
+
+	// On-demand GitHub setup, run by H with the TUI suspended
+	if githubSetup {
+		if err := internal.SetupGitHub(absPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	// Non-interactive listing for scripts and hooks
+	if listChanged {
+		separator := "\n"
+		if nullSeparated {
+			separator = "\x00"
+		}
+		os.Exit(listChangedFiles(watchPath, separator))
+	}
+
 	// Generate unique session ID for this directory
-	sessionID := generateSessionID(absPath)
+	dirSessionID := generateSessionID(absPath)
+	sessionID := dirSessionID
+	if !benchmarkMode {
+		sessionID = availableSessionID(dirSessionID)
+		writeHeartbeat(sessionID)
+	}
 
-	// Build the viewer command
-	viewerCmd := fmt.Sprintf("vinw-viewer %s", sessionID)
+	// Global config overlaid with the project's .vinw/config.toml, if any
+	config, projectConfig := internal.LoadConfigFor(watchPath)
 
-	// Print session info to terminal (copyable)
-	fmt.Printf("vinw session started\n")
-	fmt.Printf("Directory: %s\n", absPath)
-	fmt.Printf("Session ID: %s\n", sessionID)
-	fmt.Printf("\nTo open viewer, run this command in another terminal:\n")
-	fmt.Printf("%s\n", viewerCmd)
+	// Build the viewer command
+	viewerCmd := fmt.Sprintf("%s %s", viewerNameSetting(config), sessionID)
+
+	// Machine-readable benchmark output keeps stdout clean and skips setup prompts
+	quiet := benchmarkMode && jsonOutput
+	if !quiet {
+		// Print session info to terminal (copyable)
+		fmt.Printf("vinw session started\n")
+		fmt.Printf("Directory: %s\n", absPath)
+		fmt.Printf("Session ID: %s\n", sessionID)
+		if sessionID != dirSessionID {
+			fmt.Printf("Note: another vinw is already running in this directory, using a separate session\n")
+		}
+		fmt.Printf("\nTo open viewer, run this command in another terminal:\n")
+		fmt.Printf("%s\n", viewerCmd)
 
-	// Try to copy to clipboard
-	copyCmd := exec.Command("pbcopy")
-	copyCmd.Stdin = strings.NewReader(viewerCmd)
-	if err := copyCmd.Run(); err == nil {
-		fmt.Printf("\n✓ Command copied to clipboard! Just paste in a new terminal.\n")
+		// Try to copy to clipboard
+		if err := internal.CopyToClipboard(viewerCmd); err == nil {
+			fmt.Printf("\n✓ Command copied to clipboard! Just paste in a new terminal.\n")
+		}
+		fmt.Printf("\nStarting ⓥⓘⓝⓦ...\n\n")
 	}
-	fmt.Printf("\nStarting ⓥⓘⓝⓦ...\n\n")
 
 	// Initialize theme manager with session ID FIRST
-	themeManager := internal.NewThemeManagerWithSession(sessionID)
+	themeManager := internal.NewThemeManagerWithSession(sessionID, config.String("theme", ""))
 	themeManager.BroadcastTheme() // Broadcast initial theme to viewer
 
 	// Initialize GitHub repo if needed (only on first run for this directory)
-	if err := internal.InitGitHub(absPath); err != nil {
-		fmt.Printf("Error: %v\n", err)
+	if !quiet {
+		if err := internal.InitGitHub(absPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+		}
 	}
 
-	// Load gitignore
+	// Load gitignore and .vinwignore
 	gitignore := internal.NewGitIgnore(watchPath)
+	vinwignore := internal.NewVinwIgnore(watchPath)
 
 	// Benchmark mode: Run performance tests and exit
 	if benchmarkMode {
-		fmt.Fprintf(os.Stderr, "\n=== vinw Performance Benchmark ===\n")
-		fmt.Fprintf(os.Stderr, "Directory: %s\n", absPath)
-
-		// Count files
-		fileCount := 0
-		filepath.Walk(absPath, func(path string, info os.FileInfo, err error) error {
-			if err == nil && !info.IsDir() {
-				fileCount++
-			}
-			return nil
-		})
-		fmt.Fprintf(os.Stderr, "Total files: %d\n\n", fileCount)
-
-		// Benchmark git diff
-		start := time.Now()
-		diffCache := internal.GetAllGitDiffs()
-		gitDiffTime := time.Since(start)
-		fmt.Fprintf(os.Stderr, "Git diff time: %v\n", gitDiffTime)
-		fmt.Fprintf(os.Stderr, "Files with changes: %d\n\n", len(diffCache))
-
-		// Benchmark tree building (3 runs for average)
-		var treeTimes []time.Duration
-		for i := 0; i < 3; i++ {
-			start = time.Now()
-			_, _, _ = buildTreeWithMaps(watchPath, diffCache, gitignore, true, false, make(map[string]bool), false)
-			elapsed := time.Since(start)
-			treeTimes = append(treeTimes, elapsed)
-			fmt.Fprintf(os.Stderr, "Tree build #%d: %v\n", i+1, elapsed)
-		}
-
-		// Calculate average
-		var total time.Duration
-		for _, t := range treeTimes {
-			total += t
-		}
-		avg := total / time.Duration(len(treeTimes))
-		fmt.Fprintf(os.Stderr, "Average tree build: %v\n\n", avg)
-
-		fmt.Fprintf(os.Stderr, "=== Benchmark Complete ===\n")
+		report := runBenchmark(watchPath, gitignore, vinwignore)
+		if jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			encoder.Encode(report)
+		} else {
+			report.print(os.Stderr)
+		}
 		os.Exit(0)
 	}
 
-	// Get initial git diff cache
-	initialDiffCache := internal.GetAllGitDiffs()
+	// Get initial git diff cache (git is skipped entirely outside a repo)
+	inGitRepo := internal.IsGitRepo(watchPath)
+	initialDiffCache := make(map[string]int)
+	var initialDiffStats map[string]internal.DiffStat
+	repoRoot := ""
+	gitDir := ""
+	if len(roots) > 0 {
+		// Each root's repository is diffed separately
+		initialDiffStats = combinedDiffStats(watchPath, roots)
+		initialDiffCache = internal.DiffCounts(initialDiffStats)
+		inGitRepo = false
+		for _, root := range roots {
+			inGitRepo = inGitRepo || internal.IsGitRepo(root)
+		}
+	} else if inGitRepo {
+		repoRoot = internal.GetRepoRoot(watchPath)
+		gitDir = internal.GetGitDir(watchPath)
+		initialDiffStats = internal.GetAllGitDiffStats()
+		initialDiffCache = internal.DiffCounts(initialDiffStats)
+	}
 
 	// Build initial tree with gitignore support (default: ON) and nesting disabled (default: OFF)
 	respectIgnore := true
 	nestingEnabled := false // Nesting off by default for large repos
-	showHidden := false // Hidden files/folders off by default
+	showHidden := false     // Hidden files/folders off by default
 	expandedDirs := make(map[string]bool)
-	tree, fileMap, dirMap := buildTreeWithMaps(watchPath, initialDiffCache, gitignore, respectIgnore, nestingEnabled, expandedDirs, showHidden)
+	for _, root := range roots {
+		// Open each root of a combined view
+		if rel, err := filepath.Rel(watchPath, root); err == nil && rel != "." {
+			expandedDirs[rel] = true
+		}
+	}
+
+	// The startup popup can be skipped, its warnings then go to the terminal instead
+	missingTools := internal.MissingIntegrations()
+	showStartup := config.Bool("show_startup", true)
+	if skip := os.Getenv("VINW_NO_STARTUP"); skip != "" && skip != "0" && skip != "false" {
+		showStartup = false
+	}
+	if !showStartup {
+		for _, tool := range missingTools {
+			fmt.Printf("Warning: %s not found: %s\n", tool.Binary, tool.Impact)
+		}
+	}
 
 	// Initialize model
 	m := model{
-		rootPath:       watchPath,
-		tree:           tree,
-		diffCache:      initialDiffCache,
-		gitignore:      gitignore,
-		respectIgnore:  respectIgnore,
-		showHidden:     showHidden,
-		nestingEnabled: nestingEnabled,
-		expandedDirs:   expandedDirs,
-		selectedLine:   0,
-		fileMap:        fileMap,
-		dirMap:         dirMap,
-		theme:          themeManager,
-		sessionID:      sessionID,
-		showStartup:    true, // Show startup screen until user presses a key
+		rootPath:         watchPath,
+		roots:            roots,
+		diffCache:        initialDiffCache,
+		diffStats:        initialDiffStats,
+		inGitRepo:        inGitRepo,
+		repoRoot:         repoRoot,
+		gitDir:           gitDir,
+		dryRun:           dryRun,
+		gitignore:        gitignore,
+		vinwignore:       vinwignore,
+		respectIgnore:    respectIgnore,
+		showHidden:       showHidden,
+		nestingEnabled:   nestingEnabled,
+		expandedDirs:     expandedDirs,
+		selectedLine:     0,
+		theme:            themeManager,
+		sessionID:        sessionID,
+		dirSessionID:     dirSessionID,
+		editedSeen:       time.Now().UnixNano(), // Edits from before this session are already on disk
+		registers:        loadRegisters(sessionID),
+		bookmarks:        loadBookmarks(dirSessionID),
+		fullHeaderPath:   internal.GetSessionValue("header-full-path", sessionID) == "true",
+		slowDirs:         make(map[string]bool),
+		childCounts:      make(map[string]int),
+		noFollowSymlinks: noFollowSymlinks,
+		countNewFlag:     countNewFlag,
+		recentWindow:     recentWindow,
+		flatView:         flatView,
+		missingTools:     missingTools,
+		projectConfig:    projectConfig,
+		showStartup:      showStartup, // Show startup screen until user presses a key
 	}
 
-	// Initialize the cache
-	m.updateTreeCache()
-	initialContent := renderTreeWithSelectionOptimized(m.treeLines, 0)
+	m.applyConfig(config)
+	if m.countNewFiles && m.diffStats != nil {
+		m.countUntrackedLines()
+	}
+	m.diffFingerprint = m.repoFingerprint()
+	m.diffCheckedAt = time.Now()
+
+	// Build the initial tree and cache
+	m.rebuildTree()
+	if startOnChanges || config.Bool("start_on_changes", false) {
+		m.selectFirstChange()
+	}
+	initialContent := m.treeContent()
 	m.lastContent = initialContent
 
+	// Watch for file changes, falling back to stat polling if the watcher can't start
+	if m.watchInterval > 0 {
+		watchRoots := []string{watchPath}
+		if len(roots) > 0 {
+			watchRoots = roots
+		}
+		if watcher, err := internal.NewFSWatcher(watchRoots, m.gitignore, m.vinwignore, m.alwaysSkip, m.respectIgnore); err == nil {
+			m.watcher = watcher
+		} else {
+			internal.LogError("file watcher unavailable, polling instead", err)
+		}
+	}
+
+	// Items trashed by earlier runs can't be restored with ctrl+z anymore
+	go internal.PruneTrash(trashMaxAge)
+
 	// Run with fullscreen and mouse support
 	p := tea.NewProgram(
 		m,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 	)
-	if _, err := p.Run(); err != nil {
+	_, err := p.Run()
+	if m.watcher != nil {
+		m.watcher.Close()
+	}
+
+	// Release the session for the next instance in this directory
+	internal.DeleteSessionValue("heartbeat", sessionID)
+	if err != nil {
+		internal.LogError("program exited with error", err)
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -7,9 +7,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
@@ -17,6 +20,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"vinw/clipboard"
+	"vinw/config"
 )
 
 // Styles
@@ -41,22 +47,51 @@ type fileCheckMsg struct{}
 type fileContentMsg struct {
 	path    string
 	content string
+	missing bool // The selected file no longer exists
 }
 type editorFinishedMsg struct{ err error }
+type clearStatusMsg struct{ seq int }
 
 // Model
 type model struct {
-	viewport        viewport.Model
-	currentFile     string
-	content         string
-	ready           bool
-	width           int
-	height          int
-	sessionID       string   // Session ID for Skate isolation
-	mouseEnabled    bool     // Toggle for mouse mode
-	showEditorPicker bool    // Whether to show editor selection UI
-	availableEditors []string // List of available editors
-	editorCursor     int      // Selected editor in picker
+	viewport         viewport.Model
+	currentFile      string
+	content          string
+	ready            bool
+	width            int
+	height           int
+	sessionID        string      // Session ID for Skate isolation, "" when standalone
+	standaloneFile   string      // File given with --file, shown without a vinw session
+	mouseEnabled     bool        // Toggle for mouse mode
+	showEditorPicker bool        // Whether to show editor selection UI
+	availableEditors []string    // List of available editors
+	editorCursor     int         // Selected editor in picker
+	rendered         string      // Processed content before any overlays
+	bracketMatch     bool        // Whether to highlight matching brackets for the top line
+	bracketTop       int         // Top line the bracket highlight was computed for
+	commentMode      commentMode // Whether comments or code are dimmed in code files
+	hunkLines        []int       // First line (1-based) of each uncommitted hunk in the current file
+	missingFile      string      // Selected file that was found deleted, shown until another is picked
+	plainText        bool        // Whether syntax highlighting and markdown rendering are off
+	markLine         int         // Line (0-based) marked as the start of a copy range, -1 if none
+	status           string      // Transient message shown in the footer
+	statusSeq        int         // Incremented per status so stale clears are ignored
+}
+
+// Comment display modes for code files
+type commentMode int
+
+const (
+	commentsNormal commentMode = iota // Plain syntax highlighting
+	commentsDimmed                    // Dim comments so the code stands out
+	commentsOnly                      // Dim code so comments and docstrings stand out
+)
+
+// commentModeNames are shown in the footer
+var commentModeNames = map[commentMode]string{
+	commentsNormal: "normal",
+	commentsDimmed: "dim",
+	commentsOnly:   "only",
 }
 
 func (m model) Init() tea.Cmd {
@@ -113,8 +148,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Save preference and open editor
 				if m.editorCursor < len(m.availableEditors) {
 					selectedEditor := m.availableEditors[m.editorCursor]
-					setEditorPreference(m.sessionID, selectedEditor)
 					m.showEditorPicker = false
+					if selectedEditor == noEditorPreference {
+						// Explicitly forget the saved editor
+						setEditorPreference(m.sessionID, noEditorPreference)
+						return m, nil
+					}
+					setEditorPreference(m.sessionID, selectedEditor)
+					if m.currentFile == "" {
+						return m, nil
+					}
 					return m, openEditor(selectedEditor, m.currentFile)
 				}
 				return m, nil
@@ -127,6 +170,48 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "r":
 			// Manual refresh
 			return m, m.checkFile()
+		case "c":
+			// Cycle comment display: normal, dimmed, comments only
+			m.commentMode = (m.commentMode + 1) % commentMode(len(commentModeNames))
+			if m.currentFile != "" {
+				m.rendered = processFileContent(m.currentFile, m.content, m.width, m.commentMode, m.plainText)
+				m.applyBracketMatch()
+			}
+			return m, nil
+		case "p":
+			// Toggle syntax highlighting off, remembered for this session
+			m.plainText = !m.plainText
+			setPlainTextPreference(m.sessionID, m.plainText)
+			if m.currentFile != "" {
+				m.rendered = processFileContent(m.currentFile, m.content, m.width, m.commentMode, m.plainText)
+				m.applyBracketMatch()
+			}
+			return m, nil
+		case "v":
+			// Mark the top visible line as the start of a copy range, or clear the mark
+			if m.markLine >= 0 {
+				m.markLine = -1
+				return m, m.setStatus("Mark cleared")
+			}
+			m.markLine = m.viewport.YOffset
+			return m, m.setStatus(fmt.Sprintf("Marked line %d, y copies from here", m.markLine+1))
+		case "y":
+			// Copy the visible lines, or the marked range through the bottom of the screen
+			return m, m.copyLines()
+		case "]", "[":
+			// Jump to the next/previous uncommitted hunk
+			if line, ok := nextHunk(m.hunkLines, m.viewport.YOffset, msg.String() == "]"); ok {
+				m.viewport.SetYOffset(line - 1)
+				if m.bracketMatch {
+					m.applyBracketMatch()
+				}
+			}
+			return m, nil
+		case "b":
+			// Toggle bracket matching for the top visible line
+			m.bracketMatch = !m.bracketMatch
+			m.applyBracketMatch()
+			return m, nil
 		case "m":
 			// Toggle mouse mode
 			m.mouseEnabled = !m.mouseEnabled
@@ -134,6 +219,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, tea.EnableMouseCellMotion
 			}
 			return m, tea.DisableMouse
+		case "E":
+			// Change or clear the saved editor preference
+			m.availableEditors = append(detectAvailableEditors(), noEditorPreference)
+			m.showEditorPicker = true
+			m.editorCursor = 0
+			return m, nil
 		case "e":
 			// Edit current file
 			if m.currentFile == "" {
@@ -172,10 +263,35 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 
 	case editorFinishedMsg:
-		// Editor closed - refresh the file content
-		return m, m.checkFile()
+		// Editor closed - refresh the file content and tell vinw the file changed
+		return m, tea.Batch(m.checkFile(), reportEdited(m.sessionID, m.currentFile))
+
+	case clearStatusMsg:
+		if msg.seq == m.statusSeq {
+			m.status = ""
+		}
+		return m, nil
 
 	case fileContentMsg:
+		// A deleted file is shown once as gone, then forgotten so a new selection loads normally
+		if msg.missing {
+			if msg.path == m.missingFile {
+				return m, nil
+			}
+			m.missingFile = msg.path
+			m.currentFile = ""
+			m.content = ""
+			m.hunkLines = nil
+			m.rendered = fmt.Sprintf("File no longer exists:\n%s\n\nSelect another file in vinw to view it.", msg.path)
+			if m.standaloneFile != "" {
+				m.rendered = fmt.Sprintf("File no longer exists:\n%s", msg.path)
+			}
+			m.viewport.SetContent(m.rendered)
+			m.viewport.GotoTop()
+			return m, reportViewing(m.sessionID, "")
+		}
+		m.missingFile = ""
+
 		// Only update if something actually changed
 		if msg.path == "" && msg.content == "" && m.currentFile != "" {
 			// This was an empty read but we have content - keep current state
@@ -191,14 +307,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Update content if file actually changed
 		if msg.path != m.currentFile || (msg.path != "" && msg.content != m.content) {
+			var reportCmd tea.Cmd
+			if msg.path != m.currentFile {
+				reportCmd = reportViewing(m.sessionID, msg.path)
+				m.markLine = -1
+			}
 			m.currentFile = msg.path
 			m.content = msg.content
 
 			// Process content based on file type
-			processedContent := processFileContent(msg.path, msg.content, m.width)
+			m.rendered = processFileContent(msg.path, msg.content, m.width, m.commentMode, m.plainText)
+			m.hunkLines = nil
+			if !isMarkdown(msg.path) {
+				// Rendered markdown lines don't map back to the source
+				m.hunkLines = gitHunkLines(msg.path)
+			}
 
-			m.viewport.SetContent(processedContent)
+			m.viewport.SetContent(m.rendered)
 			m.viewport.GotoTop()
+			m.applyBracketMatch()
+			return m, reportCmd
 		}
 		return m, nil
 	}
@@ -207,6 +335,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.viewport, cmd = m.viewport.Update(msg)
 	cmds = append(cmds, cmd)
 
+	// Follow the top line with the bracket highlight as the view scrolls
+	if m.bracketMatch && m.viewport.YOffset != m.bracketTop {
+		m.applyBracketMatch()
+	}
+
 	return m, tea.Batch(cmds...)
 }
 
@@ -273,12 +406,73 @@ func (m model) footerView() string {
 		m.viewport.YOffset+1,
 		m.viewport.TotalLineCount(),
 		scrollPercent)
-	line2 := fmt.Sprintf("e: edit • m: mouse [%s] • r: refresh • q: quit", mouseStatus)
+	if len(m.hunkLines) > 0 {
+		line1 += fmt.Sprintf(" • hunk %d/%d", currentHunk(m.hunkLines, m.viewport.YOffset), len(m.hunkLines))
+	}
+	bracketStatus := "OFF"
+	if m.bracketMatch {
+		bracketStatus = "ON"
+	}
+	plainStatus := "OFF"
+	if m.plainText {
+		plainStatus = "ON"
+	}
+	line2 := fmt.Sprintf("e: edit • E: editor • m: mouse [%s] • b: brackets [%s] • c: comments [%s] • p: plain [%s] • v/y: mark/copy • [/]: hunks • r: refresh • q: quit", mouseStatus, bracketStatus, commentModeNames[m.commentMode], plainStatus)
+	if m.markLine >= 0 {
+		line1 += fmt.Sprintf(" • mark %d", m.markLine+1)
+	}
+	if m.status != "" {
+		line1 += " • " + m.status
+	}
 	info := line1 + "\n" + line2
 
 	return infoStyle.Width(m.width).Render(info)
 }
 
+// setStatus shows a message in the footer for a few seconds
+func (m *model) setStatus(status string) tea.Cmd {
+	m.statusSeq++
+	m.status = status
+	seq := m.statusSeq
+	return tea.Tick(3*time.Second, func(time.Time) tea.Msg {
+		return clearStatusMsg{seq: seq}
+	})
+}
+
+// copyLines copies the visible lines, or the marked range through the last visible
+// line, to the clipboard. Source text is copied when rendered lines map 1:1 to the
+// file, so line numbers and colors are left out.
+func (m *model) copyLines() tea.Cmd {
+	if m.currentFile == "" {
+		return nil
+	}
+
+	start := m.viewport.YOffset
+	end := m.viewport.YOffset + m.viewport.Height
+	if m.markLine >= 0 {
+		start = min(m.markLine, start)
+		end = max(m.markLine+1, end)
+	}
+
+	var lines []string
+	if !isMarkdown(m.currentFile) || m.plainText {
+		lines = strings.Split(m.content, "\n")
+	} else {
+		// Rendered markdown has no source mapping, copy what's on screen
+		lines = strings.Split(ansi.Strip(m.rendered), "\n")
+	}
+	end = min(end, len(lines))
+	if start >= end {
+		return nil
+	}
+
+	if err := clipboard.Copy(strings.Join(lines[start:end], "\n")); err != nil {
+		return m.setStatus("Copy failed: " + err.Error())
+	}
+	m.markLine = -1
+	return m.setStatus(fmt.Sprintf("Copied lines %d-%d", start+1, end))
+}
+
 // Commands
 
 func pollFile() tea.Cmd {
@@ -292,8 +486,11 @@ func (m model) checkFile() tea.Cmd {
 		// Update theme from Skate (doesn't affect file content)
 		updateThemeWithSession(m.sessionID)
 
-		// Get current file from Skate
-		filePath := getSelectedFileWithSession(m.sessionID)
+		// Get current file from Skate, unless a file was given directly
+		filePath := m.standaloneFile
+		if filePath == "" {
+			filePath = getSelectedFileWithSession(m.sessionID)
+		}
 		if filePath == "" {
 			// Don't immediately clear - might be a temporary Skate read issue
 			// The Update method will handle this appropriately
@@ -303,6 +500,11 @@ func (m model) checkFile() tea.Cmd {
 			}
 		}
 
+		// The file may have been deleted since it was selected
+		if _, err := os.Stat(filePath); os.IsNotExist(err) {
+			return fileContentMsg{path: filePath, missing: true}
+		}
+
 		// File exists, read it
 		content := readFileContent(filePath)
 		return fileContentMsg{
@@ -312,14 +514,53 @@ func (m model) checkFile() tea.Cmd {
 	}
 }
 
+// reportViewing tells vinw which file is on screen so it can mark it in the tree
+func reportViewing(sessionID, path string) tea.Cmd {
+	if sessionID == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		exec.Command("skate", "set", fmt.Sprintf("vinw-viewing@%s", sessionID), path).Run()
+		return nil
+	}
+}
+
+// reportEdited tells vinw a file was just edited from the viewer, so it refreshes
+// its change markers right away. The timestamp makes repeated edits distinct.
+func reportEdited(sessionID, path string) tea.Cmd {
+	if sessionID == "" || path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		signal := fmt.Sprintf("%d\t%s", time.Now().UnixNano(), path)
+		exec.Command("skate", "set", fmt.Sprintf("vinw-edited@%s", sessionID), signal).Run()
+		return nil
+	}
+}
+
 // Track current theme to avoid unnecessary updates
 var (
 	currentBg = ""
 	currentFg = ""
 )
 
+// lastThemeKey caches the last theme seen from any session for cold starts
+const lastThemeKey = "vinw-viewer-last-theme"
+
 // updateThemeWithSession updates the title style based on current theme with session
 func updateThemeWithSession(sessionID string) {
+	if sessionID == "" {
+		// Standalone: the default theme, nothing to sync with
+		if currentBg == "" && currentFg == "" {
+			currentBg, currentFg = "30", "230"
+			titleStyle = lipgloss.NewStyle().
+				Background(lipgloss.Color(currentBg)).
+				Foreground(lipgloss.Color(currentFg)).
+				Bold(true).
+				Padding(0, 1)
+		}
+		return
+	}
 	// Simple sequential reads - NO parallelization, NO goroutines, NO data races
 	cmd := exec.Command("skate", "get", fmt.Sprintf("vinw-theme-bg@%s", sessionID))
 	bgBytes, _ := cmd.Output()
@@ -337,6 +578,9 @@ func updateThemeWithSession(sessionID string) {
 			currentBg = bg
 			currentFg = fg
 
+			// Remember it so the next cold start uses these colors right away
+			exec.Command("skate", "set", lastThemeKey, bg+","+fg).Run()
+
 			// Update title style with theme colors
 			titleStyle = lipgloss.NewStyle().
 				Background(lipgloss.Color(bg)).
@@ -345,9 +589,15 @@ func updateThemeWithSession(sessionID string) {
 				Padding(0, 1)
 		}
 	} else if currentBg == "" && currentFg == "" {
-		// First time and no values in skate - use defaults
-		currentBg = "30"  // Teal from theme.go
+		// First time and no values in skate - use the last seen theme, then defaults
+		currentBg = "30" // Teal from theme.go
 		currentFg = "230"
+		cmd = exec.Command("skate", "get", lastThemeKey)
+		if lastBytes, err := cmd.Output(); err == nil {
+			if lastBg, lastFg, ok := strings.Cut(strings.TrimSpace(string(lastBytes)), ","); ok && lastBg != "" && lastFg != "" {
+				currentBg, currentFg = lastBg, lastFg
+			}
+		}
 		titleStyle = lipgloss.NewStyle().
 			Background(lipgloss.Color(currentBg)).
 			Foreground(lipgloss.Color(currentFg)).
@@ -359,13 +609,36 @@ func updateThemeWithSession(sessionID string) {
 
 // Editor helper functions
 
-// detectAvailableEditors finds all installed terminal editors
+// guiEditors launch their own window, so they are started detached instead of
+// suspending the viewer
+var guiEditors = map[string]bool{
+	"code":  true,
+	"subl":  true,
+	"zed":   true,
+	"mate":  true,
+	"gedit": true,
+	"kate":  true,
+}
+
+// isGUIEditor reports whether an editor command opens its own window
+func isGUIEditor(editor string) bool {
+	return guiEditors[filepath.Base(editor)]
+}
+
+// detectAvailableEditors finds all installed terminal and GUI editors
 func detectAvailableEditors() []string {
-	editors := []string{"nvim", "vim", "nano", "emacs", "vi"}
+	editors := []string{"nvim", "vim", "nano", "emacs", "vi", "code", "subl", "zed", "mate", "gedit", "kate"}
 	available := []string{}
+	seen := make(map[string]bool)
 
-	for _, editor := range editors {
-		if _, err := exec.LookPath(editor); err == nil {
+	// User-configured editors come first so they're the default choice
+	for _, editor := range append(customEditors(), editors...) {
+		fields := strings.Fields(editor)
+		if len(fields) == 0 || seen[editor] {
+			continue
+		}
+		seen[editor] = true
+		if _, err := exec.LookPath(fields[0]); err == nil {
 			available = append(available, editor)
 		}
 	}
@@ -373,25 +646,112 @@ func detectAvailableEditors() []string {
 	return available
 }
 
+// customEditors returns extra editor commands from VINW_EDITORS (comma-separated)
+// or the editors list in the config
+func customEditors() []string {
+	if env := os.Getenv("VINW_EDITORS"); env != "" {
+		var editors []string
+		for _, editor := range strings.Split(env, ",") {
+			if editor = strings.TrimSpace(editor); editor != "" {
+				editors = append(editors, editor)
+			}
+		}
+		return editors
+	}
+	return settings.StringList("editors", nil)
+}
+
+// settings holds ~/.vinw/config.toml overlaid by the project config, read by
+// the same parser vinw uses
+var settings *config.Config
+
+// languageOverrides maps file name patterns to chroma lexer names, from the
+// [languages] section of the config; the first matching pattern wins
+var languageOverrides [][2]string
+
+// overrideLexer returns the lexer configured for a file's name, or nil if no
+// pattern matches or the lexer name is unknown
+func overrideLexer(path string) chroma.Lexer {
+	name := filepath.Base(path)
+	for _, override := range languageOverrides {
+		if matched, err := filepath.Match(override[0], name); err == nil && matched {
+			return lexers.Get(override[1])
+		}
+	}
+	return nil
+}
+
+// noEditorPreference is stored when the user explicitly clears their editor
+// choice, so the picker is shown again on the next edit
+const noEditorPreference = "(no preference)"
+
 // getEditorPreference gets the saved editor preference for this session
 func getEditorPreference(sessionID string) string {
+	if sessionID == "" {
+		return ""
+	}
 	cmd := exec.Command("skate", "get", fmt.Sprintf("vinw-editor@%s", sessionID))
 	output, err := cmd.Output()
 	if err != nil {
 		return ""
 	}
-	return strings.TrimSpace(string(output))
+	editor := strings.TrimSpace(string(output))
+	if editor == noEditorPreference {
+		return ""
+	}
+	return editor
 }
 
 // setEditorPreference saves the editor preference for this session
 func setEditorPreference(sessionID, editor string) {
+	if sessionID == "" {
+		return
+	}
 	cmd := exec.Command("skate", "set", fmt.Sprintf("vinw-editor@%s", sessionID), editor)
 	cmd.Run()
 }
 
-// openEditor suspends the TUI and opens the file in the specified editor
+// getPlainTextPreference reports whether highlighting was turned off for this session
+func getPlainTextPreference(sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+	cmd := exec.Command("skate", "get", fmt.Sprintf("vinw-viewer-plain@%s", sessionID))
+	output, err := cmd.Output()
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(output)) == "true"
+}
+
+// setPlainTextPreference saves the highlighting choice for this session
+func setPlainTextPreference(sessionID string, plain bool) {
+	if sessionID == "" {
+		return
+	}
+	cmd := exec.Command("skate", "set", fmt.Sprintf("vinw-viewer-plain@%s", sessionID), strconv.FormatBool(plain))
+	cmd.Run()
+}
+
+// openEditor opens the file in the specified editor. Terminal editors suspend
+// the TUI until they exit; GUI editors are launched detached so the viewer stays usable
 func openEditor(editor, filePath string) tea.Cmd {
-	c := exec.Command(editor, filePath)
+	// Editor commands may carry arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		return nil
+	}
+	c := exec.Command(fields[0], append(fields[1:], filePath)...)
+	if isGUIEditor(fields[0]) {
+		return func() tea.Msg {
+			err := c.Start()
+			if err == nil {
+				// Reap the process in the background once the editor exits
+				go c.Wait()
+			}
+			return editorFinishedMsg{err}
+		}
+	}
 	return tea.ExecProcess(c, func(err error) tea.Msg {
 		return editorFinishedMsg{err}
 	})
@@ -456,7 +816,13 @@ func isMarkdown(path string) bool {
 	return ext == ".md" || ext == ".markdown" || ext == ".mdown"
 }
 
-func processFileContent(path string, content string, width int) string {
+func processFileContent(path string, content string, width int, comments commentMode, plain bool) string {
+	if plain {
+		// Highlighting turned off: plain text with line numbers for every file type
+		content, _ = truncateLongLines(content)
+		return addLineNumbers(content)
+	}
+
 	if isMarkdown(path) {
 		// Render markdown with glamour using dracula theme
 		renderer, err := glamour.NewTermRenderer(
@@ -479,10 +845,28 @@ func processFileContent(path string, content string, width int) string {
 			return content
 		}
 		return rendered
-	} else if isCodeFile(path) {
+	}
+
+	// A configured language makes any file highlightable
+	lexer := overrideLexer(path)
+	codeFile := lexer != nil || isCodeFile(path)
+
+	// Pathologically long lines (minified JS, base64 blobs) make highlighting and
+	// rendering crawl, so they're cut short and the file is shown without colors
+	content, truncated := truncateLongLines(content)
+	if truncated {
+		if codeFile {
+			return addLineNumbers(content)
+		}
+		return content
+	}
+
+	if codeFile {
 		// Syntax highlight code files
 		// Get lexer for the file type
-		lexer := lexers.Match(path)
+		if lexer == nil {
+			lexer = lexers.Match(path)
+		}
 		if lexer == nil {
 			// Try to get lexer by extension
 			ext := strings.TrimPrefix(filepath.Ext(path), ".")
@@ -520,6 +904,9 @@ func processFileContent(path string, content string, width int) string {
 		if err != nil {
 			return addLineNumbers(content)
 		}
+		if comments != commentsNormal {
+			tokens, style = dimTokens(tokens, style, comments)
+		}
 
 		// Format the tokens
 		var buf bytes.Buffer
@@ -541,6 +928,240 @@ func processFileContent(path string, content string, width int) string {
 	return content
 }
 
+// gitHunkLines returns the starting line of each uncommitted hunk in a file
+func gitHunkLines(path string) []int {
+	cmd := exec.Command("git", "diff", "-U0", "--no-color", "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var lines []int
+	for _, line := range strings.Split(string(output), "\n") {
+		// Hunk headers look like: @@ -12,3 +14,5 @@
+		if !strings.HasPrefix(line, "@@ ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+			continue
+		}
+		start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+		n, err := strconv.Atoi(start)
+		if err != nil {
+			continue
+		}
+		// Pure deletions report the line before the removed block
+		if n < 1 {
+			n = 1
+		}
+		lines = append(lines, n)
+	}
+	return lines
+}
+
+// nextHunk finds the hunk after (or before) the top line of the viewport
+func nextHunk(hunks []int, yOffset int, forward bool) (int, bool) {
+	top := yOffset + 1
+	if forward {
+		for _, line := range hunks {
+			if line > top {
+				return line, true
+			}
+		}
+		return 0, false
+	}
+	for i := len(hunks) - 1; i >= 0; i-- {
+		if hunks[i] < top {
+			return hunks[i], true
+		}
+	}
+	return 0, false
+}
+
+// currentHunk returns the 1-based index of the last hunk at or above the top line
+func currentHunk(hunks []int, yOffset int) int {
+	current := 0
+	for i, line := range hunks {
+		if line <= yOffset+1 {
+			current = i + 1
+		}
+	}
+	return current
+}
+
+// maxLineLength is the longest line the viewer renders in full
+const maxLineLength = 2000
+
+// truncateLongLines cuts lines over maxLineLength, noting how much was dropped,
+// and reports whether any line was cut
+func truncateLongLines(content string) (string, bool) {
+	lines := strings.Split(content, "\n")
+	truncated := false
+	for i, line := range lines {
+		if len(line) > maxLineLength {
+			// Back up to a rune boundary so UTF-8 isn't split
+			cut := maxLineLength
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			lines[i] = fmt.Sprintf("%s … [%d more bytes, highlighting off]", line[:cut], len(line)-cut)
+			truncated = true
+		}
+	}
+	if !truncated {
+		return content, false
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// dimColor is used for the de-emphasized side in comment display modes
+const dimColor = "#4a4a4a"
+
+// isCommentToken reports whether a token is a comment or docstring
+func isCommentToken(t chroma.TokenType) bool {
+	if t == chroma.CommentPreproc || t == chroma.CommentPreprocFile {
+		// Preprocessor directives are code
+		return false
+	}
+	return t.InCategory(chroma.Comment) || t == chroma.LiteralStringDoc
+}
+
+// dimTokens retypes either the comment or the code tokens so a single style
+// entry can dim them, returning the tokens with the adjusted style
+func dimTokens(tokens chroma.Iterator, style *chroma.Style, comments commentMode) (chroma.Iterator, *chroma.Style) {
+	dimType := chroma.Comment
+	if comments == commentsOnly {
+		dimType = chroma.Text
+	}
+
+	var retyped []chroma.Token
+	for _, token := range tokens.Tokens() {
+		if isCommentToken(token.Type) == (comments == commentsDimmed) {
+			token.Type = dimType
+		}
+		retyped = append(retyped, token)
+	}
+
+	dimmed, err := style.Builder().Add(dimType, dimColor).Build()
+	if err != nil {
+		dimmed = style
+	}
+	return chroma.Literator(retyped...), dimmed
+}
+
+// applyBracketMatch re-renders the viewport content, highlighting the partner of
+// the last unmatched opening bracket on the top visible line
+func (m *model) applyBracketMatch() {
+	m.bracketTop = m.viewport.YOffset
+	if !m.bracketMatch || !isCodeFile(m.currentFile) {
+		m.viewport.SetContent(m.rendered)
+		return
+	}
+
+	rawLines := strings.Split(m.content, "\n")
+	openLine, openCol, matchLine, matchCol, ok := findBracketMatch(rawLines, m.viewport.YOffset)
+	if !ok {
+		m.viewport.SetContent(m.rendered)
+		return
+	}
+
+	// Code files are rendered with a line number gutter (digits plus margin)
+	gutter := len(fmt.Sprintf("%d", len(strings.Split(m.rendered, "\n")))) + 1
+	lines := strings.Split(m.rendered, "\n")
+	if openLine < len(lines) {
+		lines[openLine] = highlightColumn(lines[openLine], gutter+openCol)
+	}
+	if matchLine < len(lines) {
+		lines[matchLine] = highlightColumn(lines[matchLine], gutter+matchCol)
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// findBracketMatch finds the last unmatched opening bracket on line top and
+// scans forward for its partner, returning rune columns within the raw lines
+func findBracketMatch(lines []string, top int) (openLine, openCol, matchLine, matchCol int, ok bool) {
+	if top < 0 || top >= len(lines) {
+		return 0, 0, 0, 0, false
+	}
+
+	pairs := map[rune]rune{'(': ')', '[': ']', '{': '}'}
+
+	// Find unmatched openers on the top line
+	var stack []int
+	runes := []rune(lines[top])
+	for col, r := range runes {
+		if _, isOpen := pairs[r]; isOpen {
+			stack = append(stack, col)
+		} else if r == ')' || r == ']' || r == '}' {
+			if len(stack) > 0 && pairs[runes[stack[len(stack)-1]]] == r {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if len(stack) == 0 {
+		return 0, 0, 0, 0, false
+	}
+
+	openCol = stack[len(stack)-1]
+	open := runes[openCol]
+	closing := pairs[open]
+
+	// Scan forward counting nesting of the same bracket type
+	depth := 0
+	for lineIdx := top; lineIdx < len(lines); lineIdx++ {
+		lineRunes := []rune(lines[lineIdx])
+		start := 0
+		if lineIdx == top {
+			start = openCol
+		}
+		for col := start; col < len(lineRunes); col++ {
+			switch lineRunes[col] {
+			case open:
+				depth++
+			case closing:
+				depth--
+				if depth == 0 {
+					return top, openCol, lineIdx, col, true
+				}
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// highlightColumn reverses the visible rune at col in an ANSI-styled line,
+// leaving the surrounding escape sequences intact
+func highlightColumn(line string, col int) string {
+	var result strings.Builder
+	visible := 0
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		// Copy escape sequences through without counting them
+		if runes[i] == '\x1b' && i+1 < len(runes) && runes[i+1] == '[' {
+			j := i + 2
+			for j < len(runes) && (runes[j] < '@' || runes[j] > '~') {
+				j++
+			}
+			result.WriteString(string(runes[i:min(j+1, len(runes))]))
+			i = j
+			continue
+		}
+
+		if visible == col {
+			result.WriteString("\x1b[7m")
+			result.WriteRune(runes[i])
+			result.WriteString("\x1b[27m")
+			result.WriteString(string(runes[i+1:]))
+			return result.String()
+		}
+		result.WriteRune(runes[i])
+		visible++
+	}
+	return result.String()
+}
+
 func addLineNumbers(content string) string {
 	lines := strings.Split(content, "\n")
 	maxLineNum := len(lines)
@@ -560,27 +1181,60 @@ func addLineNumbers(content string) string {
 }
 
 func main() {
-	// Get session ID from command line argument
-	var sessionID string
-	if len(os.Args) > 1 {
-		sessionID = os.Args[1]
-		fmt.Printf("Starting vinw viewer with session: %s\n", sessionID)
-	} else {
+	// Get session ID, or a file to show standalone, from the command line
+	var sessionID, standaloneFile string
+	args := os.Args[1:]
+	switch {
+	case len(args) == 2 && args[0] == "--file":
+		standaloneFile = args[1]
+	case len(args) == 1 && strings.HasPrefix(args[0], "--file="):
+		standaloneFile = strings.TrimPrefix(args[0], "--file=")
+	case len(args) == 1 && !strings.HasPrefix(args[0], "-"):
+		sessionID = args[0]
+	default:
 		fmt.Println("Usage: vinw-viewer <session-id>")
-		fmt.Println("\nGet the session ID from the vinw instance you want to connect to.")
+		fmt.Println("       vinw-viewer --file <path>")
+		fmt.Println("\nGet the session ID from the vinw instance you want to connect to,")
+		fmt.Println("or give a file to view it on its own.")
 		os.Exit(1)
 	}
 
-	fmt.Println("Waiting for file selection from vinw...")
-	fmt.Println()
+	if standaloneFile != "" {
+		absFile, err := filepath.Abs(standaloneFile)
+		if err == nil {
+			standaloneFile = absFile
+		}
+		info, err := os.Stat(standaloneFile)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if info.IsDir() {
+			fmt.Printf("Error: %s is a directory\n", standaloneFile)
+			os.Exit(1)
+		}
+	} else {
+		fmt.Printf("Starting vinw viewer with session: %s\n", sessionID)
+		fmt.Println("Waiting for file selection from vinw...")
+		fmt.Println()
+	}
 
 	// Initialize theme on startup with session
 	updateThemeWithSession(sessionID)
+	configDir := "."
+	if standaloneFile != "" {
+		configDir = filepath.Dir(standaloneFile)
+	}
+	settings, _ = config.LoadFor(configDir)
+	languageOverrides = settings.Section("languages")
 
 	p := tea.NewProgram(
 		model{
-			sessionID:    sessionID,
-			mouseEnabled: true, // Start with mouse enabled for scrolling
+			sessionID:      sessionID,
+			standaloneFile: standaloneFile,
+			mouseEnabled:   true, // Start with mouse enabled for scrolling
+			markLine:       -1,
+			plainText:      getPlainTextPreference(sessionID),
 		},
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),